@@ -0,0 +1,18 @@
+package broker
+
+import "context"
+
+type (
+	// Handler processes a single message body received from a topic.
+	// Returning an error leaves the message to the transport's retry behaviour.
+	Handler func(ctx context.Context, msg []byte) (err error)
+
+	// MessageBroker is the transport-agnostic messaging interface business code depends on,
+	// so NSQ and Redis Streams can be swapped without touching callers.
+	MessageBroker interface {
+		// Publish sends a message to the specified topic
+		Publish(ctx context.Context, topic string, msg []byte) (err error)
+		// Subscribe runs handler for every message delivered to the group on the specified topic
+		Subscribe(ctx context.Context, topic, group string, handler Handler) (err error)
+	}
+)
@@ -0,0 +1,99 @@
+package caches
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// benchmarkKeyPrefix namespaces the keys written by Benchmark.
+const benchmarkKeyPrefix = "benchmark:"
+
+type (
+	// BenchResult reports the outcome of Benchmark.
+	BenchResult struct {
+		Ops        int           // Number of operations run, half sets and half gets
+		Elapsed    time.Duration // Wall-clock time of the whole run
+		Throughput float64       // Operations per second
+		Set        Latencies     // Latency distribution of SetSingleWithTTL
+		Get        Latencies     // Latency distribution of GetSingle
+	}
+
+	// Latencies summarizes the latency distribution of one kind of operation.
+	Latencies struct {
+		P50 time.Duration
+		P95 time.Duration
+		P99 time.Duration
+		Max time.Duration
+	}
+)
+
+// Benchmark runs ops operations against the cache, alternating SetSingleWithTTL and GetSingle on the key just written,
+// and reports the throughput and latency percentiles, e.g. to compare backends or measure the cost of
+// decorators such as NewCompressed or NewEncrypted. Operations run sequentially on the calling goroutine.
+// The keys are prefixed with "benchmark:", written with a one-minute TTL and deleted afterwards,
+// so run it against a cache whose keyspace can take them.
+// Returns an error if an operation fails or ctx is done.
+func Benchmark(ctx context.Context, cache Cache, ops int) (result BenchResult, err error) {
+	value := map[string]interface{}{
+		"id":     1,
+		"name":   "benchmark",
+		"tags":   []string{"a", "b", "c"},
+		"active": true,
+	}
+	sets := make([]time.Duration, 0, (ops+1)/2)
+	gets := make([]time.Duration, 0, ops/2)
+	written := make([]string, 0, (ops+1)/2)
+	defer func() {
+		for _, key := range written {
+			_ = cache.Delete(context.Background(), key)
+		}
+	}()
+
+	started := time.Now()
+	for i := 0; i < ops; i++ {
+		if err = ctx.Err(); err != nil {
+			return result, err
+		}
+		key := fmt.Sprintf("%s%d", benchmarkKeyPrefix, i/2)
+		opStarted := time.Now()
+		if i%2 == 0 {
+			err = cache.SetSingleWithTTL(ctx, key, value, time.Minute)
+			sets = append(sets, time.Since(opStarted))
+			written = append(written, key)
+		} else {
+			_, err = cache.GetSingle(ctx, key)
+			gets = append(gets, time.Since(opStarted))
+		}
+		if err != nil {
+			return result, fmt.Errorf("cache: benchmark operation %d: %w", i, err)
+		}
+	}
+
+	result.Ops = ops
+	result.Elapsed = time.Since(started)
+	if result.Elapsed > 0 {
+		result.Throughput = float64(ops) / result.Elapsed.Seconds()
+	}
+	result.Set = newLatencies(sets)
+	result.Get = newLatencies(gets)
+	return result, nil
+}
+
+// newLatencies computes the percentiles of the samples, sorting them in place.
+func newLatencies(samples []time.Duration) (latencies Latencies) {
+	if len(samples) == 0 {
+		return latencies
+	}
+	slices.Sort(samples)
+	percentile := func(p int) time.Duration {
+		return samples[(len(samples)-1)*p/100]
+	}
+	return Latencies{
+		P50: percentile(50),
+		P95: percentile(95),
+		P99: percentile(99),
+		Max: samples[len(samples)-1],
+	}
+}
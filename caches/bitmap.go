@@ -0,0 +1,30 @@
+package caches
+
+import "context"
+
+// SetBit sets or clears the bit at offset in the Redis bitmap stored at key, growing it as needed.
+// Returns an error if the offset is negative or the command fails.
+func (r *redisCache) SetBit(ctx context.Context, key string, offset int64, value bool) (err error) {
+	bit := 0
+	if value {
+		bit = 1
+	}
+	return r.client.SetBit(ctx, key, offset, bit).Err()
+}
+
+// GetBit reports whether the bit at offset in the Redis bitmap stored at key is set.
+// Bits beyond the end of the bitmap, and bits of a missing key, are reported as unset.
+// Returns an error if the offset is negative or the command fails.
+func (r *redisCache) GetBit(ctx context.Context, key string, offset int64) (value bool, err error) {
+	bit, err := r.client.GetBit(ctx, key, offset).Result()
+	if err != nil {
+		return false, err
+	}
+	return bit == 1, nil
+}
+
+// BitCount returns the number of set bits in the Redis bitmap stored at key, 0 for a missing key.
+// Returns an error if the command fails.
+func (r *redisCache) BitCount(ctx context.Context, key string) (count int64, err error) {
+	return r.client.BitCount(ctx, key, nil).Result()
+}
@@ -0,0 +1,47 @@
+package caches
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// BLPop pops the first element of the first non-empty list among keys using BLPOP,
+// blocking up to timeout for an element to arrive. A zero timeout blocks until an element arrives or ctx is done.
+// The command runs on a dedicated connection that is closed as soon as ctx is done, so a cancelled
+// consumer returns promptly instead of waiting out the timeout. An element popped by Redis at the
+// exact moment of cancellation is lost, as with any BLPOP whose client disconnects.
+// The element is decoded with the configured codec into a SingleDataRecord.
+// Returns ErrNotFound if the timeout elapses, the context error if ctx is done,
+// or an error if the command or decoding fails.
+func (r *redisCache) BLPop(ctx context.Context, timeout time.Duration, keys ...string) (key string, value SingleDataRecord, err error) {
+	conn := r.client.Conn()
+	defer conn.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	popped, err := conn.BLPop(ctx, timeout, keys...).Result()
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", nil, ctxErr
+		}
+		if errors.Is(err, redis.Nil) {
+			return "", nil, ErrNotFound
+		}
+		return "", nil, err
+	}
+	if err = decode(r.opts.codec, popped[0], []byte(popped[1]), &value); err != nil {
+		return "", nil, err
+	}
+	return popped[0], value, nil
+}
@@ -3,15 +3,26 @@ package caches
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
 
 	"github.com/bradfitz/gomemcache/memcache"
 	"github.com/redis/go-redis/v9"
 )
 
 var (
-	_ Cache = &redisCache{}
-	_ Cache = &memcacheCache{}
+	// ErrNotFound is returned when the requested key does not exist in the cache.
+	ErrNotFound = errors.New("cache: key not found")
+)
+
+var (
+	_ Cache         = &redisCache{}
+	_ RedisCache    = &redisCache{}
+	_ Cache         = &memcacheCache{}
+	_ MemcacheCache = &memcacheCache{}
 )
 
 type (
@@ -26,21 +37,156 @@ type (
 		SetSingle(ctx context.Context, key string, value SingleDataRecord) (err error)
 		// GetSingle retrieves a single data record from the cache using the specified key.
 		GetSingle(ctx context.Context, key string) (result SingleDataRecord, err error)
+		// Lookup retrieves a single data record, reporting whether the key exists instead of returning a miss error.
+		Lookup(ctx context.Context, key string) (value SingleDataRecord, found bool, err error)
+		// SetSingleWithTTL stores a single data record in the cache with the specified key and expiration.
+		SetSingleWithTTL(ctx context.Context, key string, value SingleDataRecord, ttl time.Duration) (err error)
+		// SetNX stores a single data record only if the key does not exist yet.
+		SetNX(ctx context.Context, key string, value SingleDataRecord, ttl time.Duration) (stored bool, err error)
 
 		// SetMultiple stores multiple data records in the cache with the specified key.
 		SetMultiple(ctx context.Context, key string, value MultipleDataRecord) (err error)
 		// GetMultiple retrieves multiple data records from the cache using the specified key.
 		GetMultiple(ctx context.Context, key string) (result MultipleDataRecord, err error)
+
+		// GetAndDelete retrieves a single data record and removes it from the cache.
+		GetAndDelete(ctx context.Context, key string) (result SingleDataRecord, err error)
+
+		// ImportJSONL streams key/value records from a JSON-lines reader into the cache.
+		ImportJSONL(ctx context.Context, r io.Reader, ttl time.Duration) (count int, err error)
+
+		// Delete removes the specified key from the cache.
+		Delete(ctx context.Context, key string) (err error)
+		// Pipeline returns a builder that buffers commands and executes them together.
+		Pipeline() (pipeline Pipeline)
+
+		// GetMultipleInto retrieves multiple data records, decoding each element independently into a value from newItem.
+		GetMultipleInto(ctx context.Context, key string, newItem func() interface{}) (result MultipleDataRecord, err error)
+		// SetMultipleIndexed stores each element under its own key derived by keyFunc.
+		SetMultipleIndexed(ctx context.Context, keyFunc func(item interface{}) string, values MultipleDataRecord, ttl time.Duration) (err error)
+
+		// MergePatch applies an RFC 7386 JSON merge patch to the stored document.
+		MergePatch(ctx context.Context, key string, patch json.RawMessage, ttl time.Duration) (err error)
+
+		// Ping checks that the cache backend is reachable.
+		Ping(ctx context.Context) (err error)
+	}
+
+	// SlidingCache extends the expiration of keys as they are read, for sliding-window expiry such as sessions.
+	SlidingCache interface {
+		// GetSingleSliding retrieves a single data record and, on a hit, resets its expiration to ttl.
+		GetSingleSliding(ctx context.Context, key string, ttl time.Duration) (result SingleDataRecord, err error)
+	}
+
+	// TTLCache reports how long keys have left before they expire.
+	TTLCache interface {
+		// TTL returns the time remaining until the key expires, or NoExpiration if it never does.
+		TTL(ctx context.Context, key string) (remaining time.Duration, err error)
+	}
+
+	// PubSub broadcasts messages to every subscriber of a channel, e.g. cache invalidations across instances.
+	PubSub interface {
+		// Publish sends a message to the subscribers of a pub/sub channel.
+		Publish(ctx context.Context, channel, message string) (err error)
+		// Subscribe calls fn with every message published to a pub/sub channel until unsubscribe is called.
+		Subscribe(ctx context.Context, channel string, fn func(message string)) (unsubscribe func() error, err error)
+	}
+
+	// BitmapCache stores compact per-offset boolean flags, e.g. one bit per user ID.
+	BitmapCache interface {
+		// SetBit sets or clears the bit at offset in the bitmap stored at key.
+		SetBit(ctx context.Context, key string, offset int64, value bool) (err error)
+		// GetBit reports whether the bit at offset in the bitmap stored at key is set.
+		GetBit(ctx context.Context, key string, offset int64) (value bool, err error)
+		// BitCount returns the number of set bits in the bitmap stored at key.
+		BitCount(ctx context.Context, key string) (count int64, err error)
+	}
+
+	// CardinalityCache estimates the number of distinct elements without storing them, using HyperLogLog.
+	CardinalityCache interface {
+		// PFAdd adds elements to the HyperLogLog stored at key.
+		PFAdd(ctx context.Context, key string, elements ...string) (err error)
+		// PFCount returns the approximate number of distinct elements added to the HyperLogLog at key.
+		PFCount(ctx context.Context, key string) (count int64, err error)
+	}
+
+	// GeoCache stores named locations and answers proximity queries.
+	GeoCache interface {
+		// GeoAdd stores member at the given longitude and latitude in the geo set at key.
+		GeoAdd(ctx context.Context, key string, lon, lat float64, member string) (err error)
+		// GeoRadius returns the members within radiusKm kilometers of the given point, nearest first.
+		GeoRadius(ctx context.Context, key string, lon, lat, radiusKm float64) (members []string, err error)
+	}
+
+	// LeaderboardCache ranks members by an accumulated score, e.g. top players or most viewed items.
+	LeaderboardCache interface {
+		// IncrementScore adds delta to the score of member in the leaderboard at key and returns the new score.
+		IncrementScore(ctx context.Context, key, member string, delta float64) (score float64, err error)
+		// TopN returns the n members with the highest scores in the leaderboard at key, highest first.
+		TopN(ctx context.Context, key string, n int) (members []ScoredMember, err error)
+	}
+
+	// RedisCache extends Cache with operations that are only available on the Redis backend.
+	RedisCache interface {
+		Cache
+		SlidingCache
+		TTLCache
+		PubSub
+		BitmapCache
+		CardinalityCache
+		GeoCache
+		LeaderboardCache
+
+		// CompareAndSwap sets the key to new only if its current value equals old.
+		CompareAndSwap(ctx context.Context, key string, old, new SingleDataRecord, ttl time.Duration) (swapped bool, err error)
+		// Scan iterates over the keys matching the pattern in batches.
+		Scan(ctx context.Context, pattern string, fn func(keys []string) error) (err error)
+		// Export writes the keys matching the pattern and their values to w as JSON lines.
+		Export(ctx context.Context, pattern string, w io.Writer) (count int, err error)
+		// Count returns an approximate number of keys matching the pattern.
+		Count(ctx context.Context, pattern string) (count int64, err error)
+		// DeletePattern deletes every key matching the pattern.
+		DeletePattern(ctx context.Context, pattern string) (deleted int64, err error)
+		// AllowN reports whether a request is allowed under a fixed-window rate limit shared across instances.
+		AllowN(ctx context.Context, key string, limit int, window time.Duration) (allowed bool, err error)
+		// AppendMultiple appends elements to the records stored as a Redis list.
+		AppendMultiple(ctx context.Context, key string, values MultipleDataRecord) (err error)
+		// GetMultiplePage reads a page of the records stored as a Redis list.
+		GetMultiplePage(ctx context.Context, key string, offset, limit int64) (result MultipleDataRecord, err error)
+		// RenewIfOwner resets the key's expiration only if it still holds token, for renewing leases.
+		RenewIfOwner(ctx context.Context, key, token string, ttl time.Duration) (renewed bool, err error)
+		// SetIfGreater sets the key to value only if the key is missing or holds a lower number.
+		SetIfGreater(ctx context.Context, key string, value float64) (updated bool, err error)
+		// BLPop blocks until an element can be popped from one of the lists, the timeout elapses or ctx is done.
+		BLPop(ctx context.Context, timeout time.Duration, keys ...string) (key string, value SingleDataRecord, err error)
+	}
+
+	// MemcacheCache extends Cache with operations that are only available on the Memcache backend.
+	MemcacheCache interface {
+		Cache
+		SlidingCache
+
+		// GetWithCAS retrieves a single data record together with its compare-and-swap token.
+		GetWithCAS(ctx context.Context, key string) (result SingleDataRecord, cas uint64, err error)
+		// SetWithCAS stores a single data record only if the compare-and-swap token is still current.
+		SetWithCAS(ctx context.Context, key string, value SingleDataRecord, cas uint64) (swapped bool, err error)
+		// IncrementOrInit adds delta to the counter at key, creating it with initial if it does not exist.
+		IncrementOrInit(ctx context.Context, key string, delta, initial uint64, ttl time.Duration) (value uint64, err error)
 	}
 
 	// redisCache implements the Cache interface using Redis as the backend.
 	redisCache struct {
 		client *redis.Client
+		opts   *options
+
+		replicas    []*redis.Client // read replicas, empty when reads go to the primary
+		nextReplica atomic.Uint32   // round-robin position over replicas
 	}
 
 	// memcacheCache implements the Cache interface using Memcache as the backend.
 	memcacheCache struct {
 		client *memcache.Client
+		opts   *options
 	}
 
 	// cacheStruct wraps a Cache implementation.
@@ -49,11 +195,20 @@ type (
 	}
 )
 
+// isNotFound reports whether err means the key is missing, whether it comes as ErrNotFound
+// or as the raw miss error of a backend that does not map it yet.
+func isNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound) || errors.Is(err, redis.Nil) || errors.Is(err, memcache.ErrCacheMiss)
+}
+
 // SetSingle stores a single data record in Memcache with the specified key.
-// The value is JSON marshaled before storage.
-// Returns an error if marshaling or storage fails.
+// The value is validated and encoded with the configured codec before storage.
+// Returns an error if validation, encoding or storage fails.
 func (m *memcacheCache) SetSingle(ctx context.Context, key string, value SingleDataRecord) (err error) {
-	result, err := json.Marshal(value)
+	if err = m.opts.validate(value); err != nil {
+		return err
+	}
+	result, err := m.opts.codec.Marshal(value)
 	if err != nil {
 		return err
 	}
@@ -77,10 +232,10 @@ func (m *memcacheCache) GetSingle(ctx context.Context, key string) (result Singl
 }
 
 // SetMultiple stores multiple data records in Memcache with the specified key.
-// The value is JSON marshaled before storage.
-// Returns an error if marshaling or storage fails.
+// The value is encoded with the configured codec before storage.
+// Returns an error if encoding or storage fails.
 func (m *memcacheCache) SetMultiple(ctx context.Context, key string, value MultipleDataRecord) (err error) {
-	result, err := json.Marshal(value)
+	result, err := m.opts.codec.Marshal(value)
 	if err != nil {
 		return err
 	}
@@ -92,15 +247,18 @@ func (m *memcacheCache) SetMultiple(ctx context.Context, key string, value Multi
 }
 
 // GetMultiple retrieves multiple data records from Memcache using the specified key.
-// The data is JSON unmarshaled into a MultipleDataRecord.
-// Returns an error if the key is not found, retrieval fails, or unmarshaling fails.
+// The data is decoded with the configured codec into a MultipleDataRecord.
+// Returns ErrNotFound if the key does not exist, or an error if retrieval or decoding fails.
 func (m *memcacheCache) GetMultiple(ctx context.Context, key string) (result MultipleDataRecord, err error) {
 	resp, err := m.client.Get(key)
 	if err != nil {
+		if errors.Is(err, memcache.ErrCacheMiss) {
+			return nil, ErrNotFound
+		}
 		return nil, err
 	}
 	response := resp.Value
-	err = json.Unmarshal(response, &result)
+	err = decode(m.opts.codec, key, response, &result)
 	if err != nil {
 		return nil, err
 	}
@@ -108,22 +266,46 @@ func (m *memcacheCache) GetMultiple(ctx context.Context, key string) (result Mul
 	return result, nil
 }
 
+// Delete removes the specified key from Memcache.
+// Deleting a key that does not exist is not an error.
+// Returns an error if the deletion fails.
+func (m *memcacheCache) Delete(ctx context.Context, key string) (err error) {
+	err = m.client.Delete(key)
+	if err != nil && !errors.Is(err, memcache.ErrCacheMiss) {
+		return err
+	}
+	return nil
+}
+
+// Ping checks that every Memcache server is reachable.
+// Returns an error if any server does not respond.
+func (m *memcacheCache) Ping(ctx context.Context) (err error) {
+	return m.client.Ping()
+}
+
 // SetSingle stores a single data record in Redis with the specified key.
-// The value is stored with no expiration (0 TTL).
-// Returns an error if the storage operation fails.
+// The value is validated, encoded with the configured codec and stored with no expiration (0 TTL).
+// Returns an error if validation, encoding or the storage operation fails.
 func (r *redisCache) SetSingle(ctx context.Context, key string, value SingleDataRecord) (err error) {
-	return r.client.Set(ctx, key, value, 0).Err()
+	if err = r.opts.validate(value); err != nil {
+		return err
+	}
+	result, err := r.opts.codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(ctx, key, result, 0).Err()
 }
 
 // GetSingle retrieves a single data record from Redis using the specified key.
-// The data is JSON unmarshaled into a SingleDataRecord.
-// Returns an error if the key is not found, retrieval fails, or unmarshaling fails.
+// The data is decoded with the configured codec into a SingleDataRecord.
+// Returns an error if the key is not found, retrieval fails, or decoding fails.
 func (r *redisCache) GetSingle(ctx context.Context, key string) (result SingleDataRecord, err error) {
-	resultStr, err := r.client.Get(ctx, key).Result()
+	resultStr, err := r.get(ctx, key)
 	if err != nil {
 		return nil, err
 	}
-	err = json.Unmarshal([]byte(resultStr), &result)
+	err = decode(r.opts.codec, key, []byte(resultStr), &result)
 	if err != nil {
 		return nil, err
 	}
@@ -131,52 +313,82 @@ func (r *redisCache) GetSingle(ctx context.Context, key string) (result SingleDa
 }
 
 // SetMultiple stores multiple data records in Redis with the specified key.
-// The value is stored with no expiration (0 TTL).
-// Returns an error if the storage operation fails.
+// The value is encoded with the configured codec and stored with no expiration (0 TTL).
+// With WithListStorage each element is encoded separately and stored as an item of a Redis list.
+// Returns an error if encoding or the storage operation fails.
 func (r *redisCache) SetMultiple(ctx context.Context, key string, value MultipleDataRecord) (err error) {
-	return r.client.Set(ctx, key, value, 0).Err()
+	if r.opts.listStorage {
+		return r.setList(ctx, key, value)
+	}
+	result, err := r.opts.codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(ctx, key, result, 0).Err()
 }
 
 // GetMultiple retrieves multiple data records from Redis using the specified key.
-// The data is JSON unmarshaled into a MultipleDataRecord.
-// Returns an error if the key is not found, retrieval fails, or unmarshaling fails.
+// The data is decoded with the configured codec into a MultipleDataRecord.
+// Returns ErrNotFound if the key does not exist, the connection error as-is if Redis is unreachable,
+// or an error if decoding fails.
 func (r *redisCache) GetMultiple(ctx context.Context, key string) (result MultipleDataRecord, err error) {
-	resultStr, err := r.client.Get(ctx, key).Result()
+	if r.opts.listStorage {
+		return r.getList(ctx, key)
+	}
+	resultStr, err := r.get(ctx, key)
 	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrNotFound
+		}
 		return nil, err
 	}
-	err = json.Unmarshal([]byte(resultStr), &result)
+	err = decode(r.opts.codec, key, []byte(resultStr), &result)
 	if err != nil {
 		return nil, err
 	}
 	return result, nil
 }
 
+// Delete removes the specified key from Redis.
+// Deleting a key that does not exist is not an error.
+// Returns an error if the deletion fails.
+func (r *redisCache) Delete(ctx context.Context, key string) (err error) {
+	return r.client.Del(ctx, key).Err()
+}
+
+// Ping checks that the Redis server is reachable.
+// Returns an error if the server does not respond.
+func (r *redisCache) Ping(ctx context.Context) (err error) {
+	return r.client.Ping(ctx).Err()
+}
+
 // NewRedis creates a new Redis cache client with the specified host and port.
-// It initializes a Redis client with default settings (no password, database 0).
-// Returns a Cache interface implementation using Redis as the backend.
+// It initializes a Redis client with default settings (no password, database 0, no TLS).
+// Optional behaviour such as value validation is configured through opts.
+// Returns a RedisCache interface implementation using Redis as the backend.
 func NewRedis(
 	host, port string,
-) Cache {
-	client := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%s", host, port),
-		Password: "",
-		DB:       0,
-	})
+	opts ...Option,
+) RedisCache {
+	o := newOptions(opts...)
 	return &redisCache{
-		client: client,
+		client: newRedisClient(fmt.Sprintf("%s:%s", host, port), o),
+		opts:   o,
 	}
 }
 
 // NewMemcache creates a new Memcache client with the specified host and port.
 // It initializes a Memcache client and returns a Cache interface implementation.
-// Returns a Cache interface implementation using Memcache as the backend.
+// Optional behaviour such as value validation is configured through opts.
+// Returns a MemcacheCache interface implementation using Memcache as the backend.
 func NewMemcache(
 	host, port string,
-) Cache {
+	opts ...Option,
+) MemcacheCache {
 	client := memcache.New(fmt.Sprintf("%s:%s", host, port))
 	return &memcacheCache{
 		client: client,
+		opts:   newOptions(opts...),
 	}
 }
 
@@ -0,0 +1,28 @@
+package caches
+
+import "time"
+
+var _ Clock = realClock{}
+
+type (
+	// Clock tells the time for TTL bookkeeping, so tests can control expiry instead of sleeping.
+	Clock interface {
+		// Now returns the current time.
+		Now() time.Time
+		// After returns a channel that receives the time once d has elapsed.
+		After(d time.Duration) <-chan time.Time
+	}
+
+	// realClock is the Clock backed by the time package.
+	realClock struct{}
+)
+
+// Now returns time.Now().
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// After returns time.After(d).
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
@@ -0,0 +1,123 @@
+package caches
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+var (
+	_ Codec = JSONCodec{}
+	_ Codec = MsgpackCodec{}
+)
+
+type (
+	// Codec converts cached values to and from their stored byte representation.
+	Codec interface {
+		// Marshal encodes the value into bytes for storage.
+		Marshal(value interface{}) (data []byte, err error)
+		// Unmarshal decodes stored bytes into the value pointed to by target.
+		Unmarshal(data []byte, target interface{}) (err error)
+	}
+
+	// JSONCodec encodes values as JSON. It is the default codec of every backend.
+	// The zero value produces compact, HTML-escaped output like json.Marshal;
+	// the fields make cached values easier to read while debugging.
+	JSONCodec struct {
+		DisableHTMLEscape bool   // Keep <, > and & as-is instead of escaping them to \u003c, \u003e and \u0026
+		Indent            string // Indent nested values with this string, e.g. "  "; empty means compact output
+
+		// UseNumber decodes numbers held in interface{} values as json.Number instead of float64,
+		// so integers beyond 2^53, such as int64 IDs, keep their exact value.
+		UseNumber bool
+
+		// ExcludeTag names a struct tag, e.g. "cache", whose "-" value leaves a field out of the stored JSON,
+		// so a struct can be cached without its secrets while `json:"-"` stays reserved for API serialization.
+		// Excluded fields decode to their zero value. Values whose type holds tagged fields are encoded twice
+		// and stored with sorted object keys; values held in interface{} fields are not inspected.
+		// Empty disables exclusion.
+		ExcludeTag string
+	}
+
+	// MsgpackCodec encodes values as MessagePack, which is more compact and faster than JSON
+	// and keeps integer values as integers instead of float64.
+	MsgpackCodec struct{}
+)
+
+// Marshal encodes the value as JSON, honoring the codec's escaping and indentation settings.
+// Fields excluded by ExcludeTag are left out.
+func (c JSONCodec) Marshal(value interface{}) (data []byte, err error) {
+	if c.ExcludeTag != "" {
+		if value, err = stripExcluded(value, c.ExcludeTag); err != nil {
+			return nil, err
+		}
+	}
+	if !c.DisableHTMLEscape && c.Indent == "" {
+		return json.Marshal(value)
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(!c.DisableHTMLEscape)
+	encoder.SetIndent("", c.Indent)
+	if err = encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// Unmarshal decodes JSON data into target, keeping numbers as json.Number when UseNumber is set.
+func (c JSONCodec) Unmarshal(data []byte, target interface{}) (err error) {
+	if !c.UseNumber {
+		return json.Unmarshal(data, target)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err = decoder.Decode(target); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return errors.New("cache: invalid character after top-level JSON value")
+	}
+	return nil
+}
+
+// decode unmarshals the data stored at key into target with the codec.
+// A failure is wrapped with the key and the target type, so bad cache data can be traced to its entry,
+// while errors.Is and errors.As still reach the codec's error.
+func decode(codec Codec, key string, data []byte, target interface{}) (err error) {
+	if err = codec.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("cache: decode key %q into %s: %w", key, targetTypeName(target), err)
+	}
+	return nil
+}
+
+// targetTypeName names the type target points to, without the package qualifier for this package's types.
+func targetTypeName(target interface{}) string {
+	t := reflect.TypeOf(target)
+	if t == nil {
+		return "<nil>"
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.PkgPath() == reflect.TypeOf(MultipleDataRecord(nil)).PkgPath() && t.Name() != "" {
+		return t.Name()
+	}
+	return t.String()
+}
+
+// Marshal encodes the value as MessagePack.
+func (MsgpackCodec) Marshal(value interface{}) (data []byte, err error) {
+	return msgpack.Marshal(value)
+}
+
+// Unmarshal decodes MessagePack data into target.
+func (MsgpackCodec) Unmarshal(data []byte, target interface{}) (err error) {
+	return msgpack.Unmarshal(data, target)
+}
@@ -0,0 +1,125 @@
+package caches
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/redis/go-redis/v9"
+)
+
+// compareAndSwapScript replaces KEYS[1] with ARGV[2] only when its current value is still ARGV[1].
+// ARGV[3] holds the expiration in milliseconds, where 0 means no expiration.
+const compareAndSwapScript = `
+if redis.call('GET', KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+else
+	redis.call('SET', KEYS[1], ARGV[2])
+end
+return 1
+`
+
+// CompareAndSwap atomically sets the key to new only if its current value equals old.
+// The current value is read and compared with old as decoded values rather than as bytes, with old round-tripped
+// through the configured codec first and JSON numbers kept exact, so old may be the value read with GetSingle
+// or the original struct it was stored from, regardless of field order or number formatting.
+// The swap itself runs as a Lua script that only writes while the key still holds the bytes that were compared,
+// so a concurrent change makes it fail instead of being overwritten. A missing key never matches.
+// The new value is stored with the given TTL, or no expiration when ttl is 0.
+// Returns whether the swap happened, or an error if validation, encoding, decoding or the script execution fails.
+func (r *redisCache) CompareAndSwap(ctx context.Context, key string, old, new SingleDataRecord, ttl time.Duration) (swapped bool, err error) {
+	if err = r.opts.validate(new); err != nil {
+		return false, err
+	}
+	oldValue, err := r.opts.codec.Marshal(old)
+	if err != nil {
+		return false, err
+	}
+	newValue, err := r.opts.codec.Marshal(new)
+	if err != nil {
+		return false, err
+	}
+
+	current, err := r.client.Get(ctx, key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return false, nil
+		}
+		return false, err
+	}
+	if !bytes.Equal(current, oldValue) {
+		equal, err := r.decodedEqual(key, current, oldValue)
+		if err != nil || !equal {
+			return false, err
+		}
+	}
+
+	result, err := r.runScript(ctx, compareAndSwapScript, []string{key}, current, newValue, ttl.Milliseconds())
+	if err != nil {
+		return false, err
+	}
+	return result == int64(1), nil
+}
+
+// decodedEqual reports whether the stored and expected encodings decode to equal generic values,
+// decoding JSON numbers as json.Number so integers beyond 2^53 are compared exactly.
+func (r *redisCache) decodedEqual(key string, stored, expected []byte) (equal bool, err error) {
+	codec := preserveNumbers(r.opts.codec)
+	var storedValue, expectedValue interface{}
+	if err = decode(codec, key, stored, &storedValue); err != nil {
+		return false, err
+	}
+	if err = codec.Unmarshal(expected, &expectedValue); err != nil {
+		return false, err
+	}
+	return reflect.DeepEqual(storedValue, expectedValue), nil
+}
+
+// GetWithCAS retrieves a single data record from Memcache along with its CAS token.
+// The token can be passed to SetWithCAS to update the key only if nobody changed it in between.
+// Returns the raw byte data from the cache, ErrNotFound if the key does not exist,
+// or an error if retrieval fails.
+func (m *memcacheCache) GetWithCAS(ctx context.Context, key string) (result SingleDataRecord, cas uint64, err error) {
+	resp, err := m.client.Get(key)
+	if err != nil {
+		if errors.Is(err, memcache.ErrCacheMiss) {
+			return nil, 0, ErrNotFound
+		}
+		return nil, 0, err
+	}
+
+	return resp.Value, resp.CasID, nil
+}
+
+// SetWithCAS stores a single data record in Memcache only if cas is still the key's current token.
+// The value is validated and encoded with the configured codec before storage.
+// Returns false when the token is stale or the key has been removed since it was read,
+// or an error if validation, encoding or storage fails.
+func (m *memcacheCache) SetWithCAS(ctx context.Context, key string, value SingleDataRecord, cas uint64) (swapped bool, err error) {
+	if err = m.opts.validate(value); err != nil {
+		return false, err
+	}
+	result, err := m.opts.codec.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+
+	err = m.client.CompareAndSwap(&memcache.Item{
+		Key:   key,
+		Value: result,
+		CasID: cas,
+	})
+	if err != nil {
+		if errors.Is(err, memcache.ErrCASConflict) || errors.Is(err, memcache.ErrNotStored) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
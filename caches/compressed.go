@@ -0,0 +1,103 @@
+package caches
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// gzipMagic starts every gzip stream.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// errCompressedMergePatch is returned by MergePatch on a compressed cache, where the backend cannot see the document.
+var errCompressedMergePatch = errors.New("cache: merge patch is not supported on compressed values")
+
+// compressor gzips encoded values of at least minSize bytes. Compressed values are stored as base64 strings.
+type compressor struct {
+	minSize int
+	codec   Codec
+}
+
+// seal compresses the value if its encoding reaches minSize, and returns smaller values unchanged.
+func (c *compressor) seal(value interface{}) (stored SingleDataRecord, err error) {
+	data, err := c.codec.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < c.minSize {
+		return value, nil
+	}
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err = writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err = writer.Close(); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// open decompresses a value read from the wrapped cache into target.
+// Values without the gzip header, such as small values or entries written before compression was enabled,
+// are passed through unchanged.
+func (c *compressor) open(stored SingleDataRecord, target interface{}) (err error) {
+	if compressed, ok := c.compressed(stored); ok {
+		reader, err := gzip.NewReader(bytes.NewReader(compressed))
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+		return c.codec.Unmarshal(data, target)
+	}
+
+	// The Memcache backend returns the raw stored bytes, which are the encoded value itself.
+	if data, ok := stored.([]byte); ok {
+		return c.codec.Unmarshal(data, target)
+	}
+	data, err := c.codec.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	return c.codec.Unmarshal(data, target)
+}
+
+// compressed returns the gzip stream held by stored, if it is a compressed value.
+func (c *compressor) compressed(stored SingleDataRecord) (data []byte, ok bool) {
+	encoded, ok := stored.(string)
+	if !ok {
+		raw, isBytes := stored.([]byte)
+		if !isBytes || json.Unmarshal(raw, &encoded) != nil {
+			return nil, false
+		}
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || !bytes.HasPrefix(data, gzipMagic) {
+		return nil, false
+	}
+	return data, true
+}
+
+// NewCompressed wraps the cache so values whose JSON encoding is at least minSize bytes are gzip-compressed,
+// saving memory and bandwidth for large blobs. Compressed values are stored as base64 strings;
+// smaller values are stored unchanged. Reads detect the gzip header and pass other values through,
+// so a cache populated before compression was enabled keeps working during the transition.
+// Multiple records are compressed as a whole, and MergePatch is not supported.
+func NewCompressed(cache Cache, minSize int) Cache {
+	c := &compressor{minSize: minSize, codec: JSONCodec{}}
+	return &transformCache{
+		Cache:         cache,
+		seal:          c.seal,
+		open:          c.open,
+		codec:         c.codec,
+		mergePatchErr: errCompressedMergePatch,
+	}
+}
@@ -0,0 +1,48 @@
+package caches
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+// maxIncrementOrInitAttempts bounds how often IncrementOrInit retries when the key is deleted
+// between a lost initialization race and the following increment.
+const maxIncrementOrInitAttempts = 3
+
+// errIncrementOrInitConflict is returned when IncrementOrInit keeps losing races against concurrent deletes.
+var errIncrementOrInitConflict = errors.New("cache: increment or init kept conflicting")
+
+// IncrementOrInit atomically adds delta to the counter stored at key and returns the new value.
+// If the key does not exist it is created with the value initial, which is returned as-is without adding delta,
+// and expires after ttl (0 means no expiration). When several callers initialize the key at once,
+// exactly one Add succeeds and the others increment the value it stored.
+// The counter is stored as a decimal string, as memcache's incr requires, rather than with the codec.
+// Returns an error if the stored value is not a number or a memcache operation fails.
+func (m *memcacheCache) IncrementOrInit(ctx context.Context, key string, delta, initial uint64, ttl time.Duration) (value uint64, err error) {
+	for attempt := 0; attempt < maxIncrementOrInitAttempts; attempt++ {
+		value, err = m.client.Increment(key, delta)
+		if err == nil {
+			return value, nil
+		}
+		if !errors.Is(err, memcache.ErrCacheMiss) {
+			return 0, err
+		}
+
+		err = m.client.Add(&memcache.Item{
+			Key:        key,
+			Value:      []byte(strconv.FormatUint(initial, 10)),
+			Expiration: memcacheExpiration(ttl),
+		})
+		if err == nil {
+			return initial, nil
+		}
+		if !errors.Is(err, memcache.ErrNotStored) {
+			return 0, err
+		}
+	}
+	return 0, errIncrementOrInitConflict
+}
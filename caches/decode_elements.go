@@ -0,0 +1,206 @@
+package caches
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/redis/go-redis/v9"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// ElementDecodeError reports that a single element of a cached MultipleDataRecord could not be decoded.
+type ElementDecodeError struct {
+	Index int   // The position of the element in the cached array
+	Err   error // The decoding error
+}
+
+// Error describes the failing element.
+func (e *ElementDecodeError) Error() string {
+	return fmt.Sprintf("cache: decode element %d: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the underlying decoding error.
+func (e *ElementDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// rawElements receives an encoded array without decoding its elements, keeping the encoding of each,
+// so they can be decoded straight into their target type. It decodes from both JSON and MessagePack,
+// and so through every codec of this package.
+type rawElements struct {
+	items   [][]byte
+	msgpack bool // whether the items are MessagePack rather than JSON
+}
+
+// UnmarshalJSON splits a JSON array into its elements.
+func (r *rawElements) UnmarshalJSON(data []byte) (err error) {
+	var items []json.RawMessage
+	if err = json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	r.items = make([][]byte, len(items))
+	for i, item := range items {
+		r.items[i] = item
+	}
+	return nil
+}
+
+// DecodeMsgpack splits a MessagePack array into its elements.
+func (r *rawElements) DecodeMsgpack(decoder *msgpack.Decoder) (err error) {
+	r.msgpack = true
+	n, err := decoder.DecodeArrayLen()
+	if err != nil {
+		return err
+	}
+	r.items = make([][]byte, 0, max(n, 0))
+	for i := 0; i < n; i++ {
+		item, err := decoder.DecodeRaw()
+		if err != nil {
+			return err
+		}
+		r.items = append(r.items, item)
+	}
+	return nil
+}
+
+// codec returns the codec to decode the items with, keeping the settings of the JSON codec
+// the array was read with, such as UseNumber.
+func (r *rawElements) codec(outer Codec) Codec {
+	if r.msgpack {
+		return MsgpackCodec{}
+	}
+	switch c := outer.(type) {
+	case JSONCodec:
+		return c
+	case MarkedCodec:
+		if codec, ok := c.Write.(JSONCodec); ok {
+			return codec
+		}
+		if codec, ok := c.Legacy.(JSONCodec); ok {
+			return codec
+		}
+	}
+	return JSONCodec{}
+}
+
+// splitsArrays reports whether the codec can decode arrays into rawElements; custom codecs may not.
+func splitsArrays(codec Codec) bool {
+	switch codec.(type) {
+	case JSONCodec, MsgpackCodec, MarkedCodec:
+		return true
+	}
+	return false
+}
+
+// decodeArray decodes the array stored at key into fresh values from newItem, decoding each element
+// from its own encoding so no precision is lost to an intermediate generic value.
+// Codecs that cannot split arrays decode the array generically first, and re-encode each element.
+// Returns the elements that decode, and an error joining an *ElementDecodeError for each that fails,
+// or an error if the array itself cannot be decoded.
+func decodeArray(codec Codec, key string, data []byte, newItem func() interface{}) (result MultipleDataRecord, err error) {
+	if !splitsArrays(codec) {
+		var values MultipleDataRecord
+		if err = decode(codec, key, data, &values); err != nil {
+			return nil, err
+		}
+		return decodeValues(codec, values, newItem)
+	}
+	raw := &rawElements{}
+	if err = decode(codec, key, data, raw); err != nil {
+		return nil, err
+	}
+	return decodeElements(raw.codec(codec), raw.items, newItem)
+}
+
+// decodeElements decodes every encoded element into a fresh value from newItem, independently.
+// Elements that decode are returned in order; each failure is reported as an *ElementDecodeError
+// joined into the returned error, so one corrupt element does not discard the rest.
+func decodeElements(codec Codec, items [][]byte, newItem func() interface{}) (result MultipleDataRecord, err error) {
+	result = make(MultipleDataRecord, 0, len(items))
+	var errs []error
+	for i, data := range items {
+		item := newItem()
+		if err = codec.Unmarshal(data, item); err != nil {
+			errs = append(errs, &ElementDecodeError{Index: i, Err: err})
+			continue
+		}
+		result = append(result, item)
+	}
+	return result, errors.Join(errs...)
+}
+
+// decodeValues re-encodes every generically decoded element and decodes it into a fresh value from newItem,
+// for codecs that cannot split arrays. Failures are reported as by decodeElements.
+func decodeValues(codec Codec, values MultipleDataRecord, newItem func() interface{}) (result MultipleDataRecord, err error) {
+	items := make([][]byte, 0, len(values))
+	var errs []error
+	for i, value := range values {
+		data, err := codec.Marshal(value)
+		if err != nil {
+			errs = append(errs, &ElementDecodeError{Index: i, Err: err})
+			continue
+		}
+		items = append(items, data)
+	}
+	result, err = decodeElements(codec, items, newItem)
+	return result, errors.Join(append(errs, err)...)
+}
+
+// GetMultipleInto retrieves multiple data records from Redis and decodes each element into a value from newItem.
+// Elements are decoded independently: the successfully decoded ones are returned together with an error
+// joining an *ElementDecodeError for every element that failed, instead of failing the whole read.
+// Returns ErrNotFound if the key does not exist, or an error if retrieval or decoding of the array fails.
+func (r *redisCache) GetMultipleInto(ctx context.Context, key string, newItem func() interface{}) (result MultipleDataRecord, err error) {
+	if r.opts.listStorage {
+		items, err := r.client.LRange(ctx, key, 0, -1).Result()
+		if err != nil {
+			return nil, err
+		}
+		if len(items) == 0 {
+			return nil, ErrNotFound
+		}
+		encoded := make([][]byte, len(items))
+		for i, item := range items {
+			encoded[i] = []byte(item)
+		}
+		return decodeElements(r.opts.codec, encoded, newItem)
+	}
+	data, err := r.get(ctx, key)
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return decodeArray(r.opts.codec, key, []byte(data), newItem)
+}
+
+// GetMultipleInto retrieves multiple data records from Memcache and decodes each element into a value from newItem.
+// Elements are decoded independently: the successfully decoded ones are returned together with an error
+// joining an *ElementDecodeError for every element that failed, instead of failing the whole read.
+// Returns ErrNotFound if the key does not exist, or an error if retrieval or decoding of the array fails.
+func (m *memcacheCache) GetMultipleInto(ctx context.Context, key string, newItem func() interface{}) (result MultipleDataRecord, err error) {
+	resp, err := m.client.Get(key)
+	if err != nil {
+		if errors.Is(err, memcache.ErrCacheMiss) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return decodeArray(m.opts.codec, key, resp.Value, newItem)
+}
+
+// GetMultipleInto retrieves multiple data records from memory and decodes each element into a value from newItem.
+// Elements are decoded independently: the successfully decoded ones are returned together with an error
+// joining an *ElementDecodeError for every element that failed, instead of failing the whole read.
+// Returns ErrNotFound if the key does not exist or has expired, or an error if decoding of the array fails.
+func (m *inMemoryCache) GetMultipleInto(ctx context.Context, key string, newItem func() interface{}) (result MultipleDataRecord, err error) {
+	data, err := m.get(key)
+	if err != nil {
+		return nil, err
+	}
+	return decodeArray(m.opts.codec, key, data, newItem)
+}
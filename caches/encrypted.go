@@ -0,0 +1,113 @@
+package caches
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrDecrypt is returned when a cached value cannot be decrypted with any of the configured keys.
+	ErrDecrypt = errors.New("cache: value cannot be decrypted")
+	// errEncryptedMergePatch is returned by MergePatch on an encrypted cache, where the backend cannot see the document.
+	errEncryptedMergePatch = errors.New("cache: merge patch is not supported on encrypted values")
+)
+
+// encrypter encrypts values with AES-GCM. Sealed values are base64 strings holding the nonce followed by the ciphertext.
+type encrypter struct {
+	encrypt cipher.AEAD   // the primary key, used for writing and tried first for reading
+	decrypt []cipher.AEAD // every key values may be decrypted with, primary first
+	codec   Codec         // encodes plaintext values before encryption
+}
+
+// seal encodes and encrypts the value into the string stored in the wrapped cache.
+func (e *encrypter) seal(value interface{}) (sealed SingleDataRecord, err error) {
+	plaintext, err := e.codec.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, e.encrypt.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.EncodeToString(e.encrypt.Seal(nonce, nonce, plaintext, nil)), nil
+}
+
+// open decrypts a value read from the wrapped cache and decodes it into target.
+// Each key is tried in turn, so values written before a key rotation stay readable.
+func (e *encrypter) open(stored SingleDataRecord, target interface{}) (err error) {
+	encoded, ok := storedString(stored)
+	if !ok {
+		return ErrDecrypt
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return ErrDecrypt
+	}
+	for _, aead := range e.decrypt {
+		if len(data) < aead.NonceSize() {
+			continue
+		}
+		nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+		if plaintext, err := aead.Open(nil, nonce, ciphertext, nil); err == nil {
+			return e.codec.Unmarshal(plaintext, target)
+		}
+	}
+	return ErrDecrypt
+}
+
+// storedString returns the string a sealed value was stored as.
+// The Memcache backend returns the raw stored bytes, the JSON encoding of the string, which is decoded.
+func storedString(stored SingleDataRecord) (value string, ok bool) {
+	switch v := stored.(type) {
+	case string:
+		return v, true
+	case []byte:
+		if err := json.Unmarshal(v, &value); err != nil {
+			return string(v), true
+		}
+		return value, true
+	default:
+		return "", false
+	}
+}
+
+// newAEAD creates an AES-GCM cipher for the key, which must be 16, 24 or 32 bytes long.
+func newAEAD(key []byte) (aead cipher.AEAD, err error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// NewEncrypted wraps the cache so values are encrypted at rest with AES-GCM.
+// Values are encoded as JSON, encrypted with key under a random nonce, and stored as a base64 string
+// holding the nonce and ciphertext; reads decrypt and decode them, so callers see plaintext values.
+// To rotate keys, pass the new key as key and the previous ones as decryptionKeys: new writes use key,
+// and existing values stay readable until they are rewritten or expire.
+// Keys must be 16, 24 or 32 bytes long, selecting AES-128, AES-192 or AES-256.
+// Multiple records are encrypted as a whole, and MergePatch is not supported.
+// It panics if a key has an invalid length.
+func NewEncrypted(cache Cache, key []byte, decryptionKeys ...[]byte) Cache {
+	e := &encrypter{codec: JSONCodec{}}
+	for i, k := range append([][]byte{key}, decryptionKeys...) {
+		aead, err := newAEAD(k)
+		if err != nil {
+			panic(fmt.Sprintf("caches: NewEncrypted: key %d: %v", i, err))
+		}
+		e.decrypt = append(e.decrypt, aead)
+	}
+	e.encrypt = e.decrypt[0]
+	return &transformCache{
+		Cache:         cache,
+		seal:          e.seal,
+		open:          e.open,
+		codec:         e.codec,
+		mergePatchErr: errEncryptedMergePatch,
+	}
+}
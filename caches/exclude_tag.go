@@ -0,0 +1,205 @@
+package caches
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// excludedTypes caches, per type and tag, whether a type holds struct fields excluded by the tag.
+var excludedTypes sync.Map
+
+// excludedKey identifies an entry of excludedTypes.
+type excludedKey struct {
+	t   reflect.Type
+	tag string
+}
+
+// stripExcluded returns value ready for encoding as JSON without the struct fields tagged `<tag>:"-"`.
+// Values whose type statically holds no excluded field are returned unchanged. Others are encoded as JSON,
+// decoded into a generic document with exact numbers, and returned with the keys of the excluded fields deleted.
+// Values held in interface{} fields and types with their own JSON or text encoding are not inspected.
+// Returns an error if the value cannot be encoded.
+func stripExcluded(value interface{}, tag string) (stripped interface{}, err error) {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() || !hasExcluded(v.Type(), tag, nil) {
+		return value, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var document interface{}
+	if err = (JSONCodec{UseNumber: true}).Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	deleteExcluded(v, document, tag)
+	return document, nil
+}
+
+// hasExcluded reports whether values of type t can hold a struct field excluded by the tag.
+// seen guards against recursive types.
+func hasExcluded(t reflect.Type, tag string, seen map[reflect.Type]bool) (excluded bool) {
+	key := excludedKey{t: t, tag: tag}
+	if cached, ok := excludedTypes.Load(key); ok {
+		return cached.(bool)
+	}
+	if seen[t] {
+		return false
+	}
+	if seen == nil {
+		// Only complete results are cached; nested ones may have been cut short by the recursion guard.
+		seen = make(map[reflect.Type]bool)
+		defer func() { excludedTypes.Store(key, excluded) }()
+	}
+	seen[t] = true
+
+	if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return hasExcluded(t.Elem(), tag, seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() && !field.Anonymous {
+				continue
+			}
+			if field.Tag.Get(tag) == "-" || hasExcluded(field.Type, tag, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// deleteExcluded walks v alongside its decoded JSON document and deletes the keys of the fields excluded by the tag.
+func deleteExcluded(v reflect.Value, document interface{}, tag string) {
+	if document == nil || !hasExcluded(v.Type(), tag, nil) {
+		return
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			deleteExcluded(v.Elem(), document, tag)
+		}
+	case reflect.Slice, reflect.Array:
+		elements, _ := document.([]interface{})
+		for i := 0; i < v.Len() && i < len(elements); i++ {
+			deleteExcluded(v.Index(i), elements[i], tag)
+		}
+	case reflect.Map:
+		object, _ := document.(map[string]interface{})
+		iter := v.MapRange()
+		for iter.Next() {
+			if name, ok := mapKeyName(iter.Key()); ok {
+				deleteExcluded(iter.Value(), object[name], tag)
+			}
+		}
+	case reflect.Struct:
+		if object, ok := document.(map[string]interface{}); ok {
+			deleteExcludedFields(v, object, tag)
+		}
+	}
+}
+
+// deleteExcludedFields deletes the keys of the struct's excluded fields from the decoded object,
+// and strips the values of its other fields. Embedded structs without a JSON name share the object,
+// since encoding/json promotes their fields into it.
+func deleteExcludedFields(v reflect.Value, object map[string]interface{}, tag string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		if field.Tag.Get("json") == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		value := v.Field(i)
+		if field.Anonymous && name == "" {
+			if value.Kind() == reflect.Pointer {
+				if value.IsNil() {
+					continue
+				}
+				value = value.Elem()
+			}
+			if value.Kind() == reflect.Struct {
+				if field.Tag.Get(tag) == "-" {
+					deletePromoted(value.Type(), object)
+					continue
+				}
+				deleteExcludedFields(value, object, tag)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if field.Tag.Get(tag) == "-" {
+			delete(object, name)
+			continue
+		}
+		deleteExcluded(value, object[name], tag)
+	}
+}
+
+// deletePromoted deletes the keys of the fields an excluded embedded struct of type t promotes into the object.
+func deletePromoted(t reflect.Type, object map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		switch {
+		case field.Tag.Get("json") == "-":
+		case field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct:
+			deletePromoted(fieldType, object)
+		case field.IsExported():
+			if name == "" {
+				name = field.Name
+			}
+			delete(object, name)
+		}
+	}
+}
+
+// mapKeyName returns the JSON object key encoding/json writes for a map key.
+// The boolean is false for keys it cannot name, including text-marshaled keys of maps
+// reached through unexported embedded structs, whose methods cannot be called through reflection.
+func mapKeyName(key reflect.Value) (name string, ok bool) {
+	if key.Kind() == reflect.String {
+		return key.String(), true
+	}
+	if key.Type().Implements(textMarshalerType) {
+		if !key.CanInterface() {
+			return "", false
+		}
+		text, err := key.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err == nil
+	}
+	switch key.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if key.CanInt() {
+			return strconv.FormatInt(key.Int(), 10), true
+		}
+		return strconv.FormatUint(key.Uint(), 10), true
+	}
+	return "", false
+}
@@ -0,0 +1,124 @@
+package caches
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var _ Cache = &expvarCache{}
+
+// expvarMu serializes looking up and publishing the maps of NewExpvar, so concurrent calls with the same name share one map.
+var expvarMu sync.Mutex
+
+// expvarCache counts the hits, misses and errors of the wrapped cache in an expvar.Map.
+// Operations it does not override are passed through uncounted.
+type expvarCache struct {
+	Cache
+	vars *expvar.Map
+}
+
+// countRead records the outcome of a read: a hit, a miss, or an error.
+func (e *expvarCache) countRead(err error) {
+	switch {
+	case err == nil:
+		e.vars.Add("hits", 1)
+	case isNotFound(err):
+		e.vars.Add("misses", 1)
+	default:
+		e.vars.Add("errors", 1)
+	}
+}
+
+// countWrite records an error if the write failed.
+func (e *expvarCache) countWrite(err error) {
+	if err != nil {
+		e.vars.Add("errors", 1)
+	}
+}
+
+// SetSingle stores the value, counting a failure.
+func (e *expvarCache) SetSingle(ctx context.Context, key string, value SingleDataRecord) (err error) {
+	err = e.Cache.SetSingle(ctx, key, value)
+	e.countWrite(err)
+	return err
+}
+
+// GetSingle reads the value, counting a hit, a miss or a failure.
+func (e *expvarCache) GetSingle(ctx context.Context, key string) (result SingleDataRecord, err error) {
+	result, err = e.Cache.GetSingle(ctx, key)
+	e.countRead(err)
+	return result, err
+}
+
+// Lookup reads the value, counting a hit, a miss or a failure.
+func (e *expvarCache) Lookup(ctx context.Context, key string) (value SingleDataRecord, found bool, err error) {
+	return lookup(e.GetSingle(ctx, key))
+}
+
+// SetSingleWithTTL stores the value with the TTL, counting a failure.
+func (e *expvarCache) SetSingleWithTTL(ctx context.Context, key string, value SingleDataRecord, ttl time.Duration) (err error) {
+	err = e.Cache.SetSingleWithTTL(ctx, key, value, ttl)
+	e.countWrite(err)
+	return err
+}
+
+// SetNX stores the value if the key is missing, counting a failure.
+func (e *expvarCache) SetNX(ctx context.Context, key string, value SingleDataRecord, ttl time.Duration) (stored bool, err error) {
+	stored, err = e.Cache.SetNX(ctx, key, value, ttl)
+	e.countWrite(err)
+	return stored, err
+}
+
+// SetMultiple stores the values, counting a failure.
+func (e *expvarCache) SetMultiple(ctx context.Context, key string, value MultipleDataRecord) (err error) {
+	err = e.Cache.SetMultiple(ctx, key, value)
+	e.countWrite(err)
+	return err
+}
+
+// GetMultiple reads the values, counting a hit, a miss or a failure.
+func (e *expvarCache) GetMultiple(ctx context.Context, key string) (result MultipleDataRecord, err error) {
+	result, err = e.Cache.GetMultiple(ctx, key)
+	e.countRead(err)
+	return result, err
+}
+
+// GetAndDelete reads and removes the value, counting a hit, a miss or a failure.
+func (e *expvarCache) GetAndDelete(ctx context.Context, key string) (result SingleDataRecord, err error) {
+	result, err = e.Cache.GetAndDelete(ctx, key)
+	e.countRead(err)
+	return result, err
+}
+
+// Delete removes the key, counting a failure.
+func (e *expvarCache) Delete(ctx context.Context, key string) (err error) {
+	err = e.Cache.Delete(ctx, key)
+	e.countWrite(err)
+	return err
+}
+
+// NewExpvar wraps the cache so the hits and misses of its reads, and the errors of its reads and writes,
+// are counted in an expvar.Map published under name, with the keys "hits", "misses" and "errors".
+// The counters show up on /debug/vars when the expvar handler is served, without any metrics dependency.
+// Wrapping several caches with the same name adds to the same counters.
+// It panics if name is already published as a variable that is not an expvar.Map.
+func NewExpvar(cache Cache, name string) Cache {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+	var vars *expvar.Map
+	switch published := expvar.Get(name).(type) {
+	case nil:
+		vars = expvar.NewMap(name)
+	case *expvar.Map:
+		vars = published
+	default:
+		panic(fmt.Sprintf("caches: NewExpvar: %s is already published as %T", name, published))
+	}
+	for _, counter := range []string{"hits", "misses", "errors"} {
+		vars.Add(counter, 0)
+	}
+	return &expvarCache{Cache: cache, vars: vars}
+}
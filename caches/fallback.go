@@ -0,0 +1,202 @@
+package caches
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+)
+
+var _ Cache = &fallbackCache{}
+
+// fallbackCache tries a chain of caches in order, e.g. Redis, then Memcache, then in-memory.
+// Operations it does not override, such as Pipeline, go to the first cache only.
+type fallbackCache struct {
+	Cache
+	caches []Cache
+}
+
+// readFirst calls read on each cache in order and returns the first successful result.
+// Both misses, whether reported as ErrNotFound or as a backend's raw miss error, and connection errors
+// move on to the next cache.
+// Returns ErrNotFound if every cache missed, or the errors of the caches that failed for another reason joined otherwise.
+func readFirst[T any](caches []Cache, read func(cache Cache) (T, error)) (result T, err error) {
+	var errs []error
+	for _, cache := range caches {
+		result, err = read(cache)
+		if err == nil {
+			return result, nil
+		}
+		if !isNotFound(err) {
+			errs = append(errs, err)
+		}
+	}
+	var zero T
+	if len(errs) == 0 {
+		return zero, ErrNotFound
+	}
+	return zero, errors.Join(errs...)
+}
+
+// writeAll calls write on every cache, best-effort.
+// Returns nil if at least one cache accepted the write, or all the errors joined otherwise.
+func writeAll(caches []Cache, write func(cache Cache) error) (err error) {
+	var errs []error
+	for _, cache := range caches {
+		if err = write(cache); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) < len(caches) {
+		return nil
+	}
+	return errors.Join(errs...)
+}
+
+// SetSingle stores the value in every cache of the chain, best-effort.
+func (f *fallbackCache) SetSingle(ctx context.Context, key string, value SingleDataRecord) (err error) {
+	return writeAll(f.caches, func(cache Cache) error {
+		return cache.SetSingle(ctx, key, value)
+	})
+}
+
+// GetSingle returns the value from the first cache in the chain that has it.
+func (f *fallbackCache) GetSingle(ctx context.Context, key string) (result SingleDataRecord, err error) {
+	return readFirst(f.caches, func(cache Cache) (SingleDataRecord, error) {
+		return cache.GetSingle(ctx, key)
+	})
+}
+
+// SetSingleWithTTL stores the value with the TTL in every cache of the chain, best-effort.
+func (f *fallbackCache) SetSingleWithTTL(ctx context.Context, key string, value SingleDataRecord, ttl time.Duration) (err error) {
+	return writeAll(f.caches, func(cache Cache) error {
+		return cache.SetSingleWithTTL(ctx, key, value, ttl)
+	})
+}
+
+// SetNX stores the value only if the key is missing, as decided by the first reachable cache of the chain.
+// Once that cache has stored it, the value is copied to the other caches, best-effort,
+// so SetNX keeps its meaning as a claim while the other caches can still serve reads.
+// Returns the errors of every cache joined if none is reachable.
+func (f *fallbackCache) SetNX(ctx context.Context, key string, value SingleDataRecord, ttl time.Duration) (stored bool, err error) {
+	var errs []error
+	for i, cache := range f.caches {
+		stored, err = cache.SetNX(ctx, key, value, ttl)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if stored {
+			for j, other := range f.caches {
+				if j != i {
+					_ = other.SetSingleWithTTL(ctx, key, value, ttl)
+				}
+			}
+		}
+		return stored, nil
+	}
+	return false, errors.Join(errs...)
+}
+
+// SetMultiple stores the values in every cache of the chain, best-effort.
+func (f *fallbackCache) SetMultiple(ctx context.Context, key string, value MultipleDataRecord) (err error) {
+	return writeAll(f.caches, func(cache Cache) error {
+		return cache.SetMultiple(ctx, key, value)
+	})
+}
+
+// GetMultiple returns the values from the first cache in the chain that has them.
+func (f *fallbackCache) GetMultiple(ctx context.Context, key string) (result MultipleDataRecord, err error) {
+	return readFirst(f.caches, func(cache Cache) (MultipleDataRecord, error) {
+		return cache.GetMultiple(ctx, key)
+	})
+}
+
+// GetMultipleInto decodes the values from the first cache in the chain that has them.
+func (f *fallbackCache) GetMultipleInto(ctx context.Context, key string, newItem func() interface{}) (result MultipleDataRecord, err error) {
+	return readFirst(f.caches, func(cache Cache) (MultipleDataRecord, error) {
+		return cache.GetMultipleInto(ctx, key, newItem)
+	})
+}
+
+// GetAndDelete returns the value from the first cache in the chain that has it,
+// and removes the key from every cache so it cannot be read again through a later one.
+func (f *fallbackCache) GetAndDelete(ctx context.Context, key string) (result SingleDataRecord, err error) {
+	result, err = readFirst(f.caches, func(cache Cache) (SingleDataRecord, error) {
+		return cache.GetAndDelete(ctx, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	_ = f.Delete(ctx, key)
+	return result, nil
+}
+
+// Delete removes the key from every cache of the chain, best-effort.
+func (f *fallbackCache) Delete(ctx context.Context, key string) (err error) {
+	return writeAll(f.caches, func(cache Cache) error {
+		return cache.Delete(ctx, key)
+	})
+}
+
+// SetMultipleIndexed stores the elements in every cache of the chain, best-effort.
+func (f *fallbackCache) SetMultipleIndexed(ctx context.Context, keyFunc func(item interface{}) string, values MultipleDataRecord, ttl time.Duration) (err error) {
+	return writeAll(f.caches, func(cache Cache) error {
+		return cache.SetMultipleIndexed(ctx, keyFunc, values, ttl)
+	})
+}
+
+// ImportJSONL imports the records into every cache of the chain, best-effort.
+// The input is buffered in memory, since it can only be read once.
+// Returns the number of records imported by the first cache that succeeded.
+func (f *fallbackCache) ImportJSONL(ctx context.Context, r io.Reader, ttl time.Duration) (count int, err error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+	imported := -1
+	err = writeAll(f.caches, func(cache Cache) error {
+		n, err := cache.ImportJSONL(ctx, bytes.NewReader(data), ttl)
+		if err == nil && imported < 0 {
+			imported = n
+		}
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return imported, nil
+}
+
+// MergePatch applies the patch to the document in every cache of the chain, best-effort.
+func (f *fallbackCache) MergePatch(ctx context.Context, key string, patch json.RawMessage, ttl time.Duration) (err error) {
+	return writeAll(f.caches, func(cache Cache) error {
+		return cache.MergePatch(ctx, key, patch, ttl)
+	})
+}
+
+// Ping succeeds if at least one cache of the chain is reachable.
+func (f *fallbackCache) Ping(ctx context.Context) (err error) {
+	_, err = readFirst(f.caches, func(cache Cache) (struct{}, error) {
+		return struct{}{}, cache.Ping(ctx)
+	})
+	return err
+}
+
+// NewFallback chains several caches for resilience, e.g. Redis, then Memcache, then in-memory.
+// Reads try each cache in order and return the first success; misses and connection errors
+// both move on to the next cache. Writes, merge patches, imports and deletes go to every cache, best-effort,
+// and only fail if every cache failed. SetNX is decided by the first reachable cache and then copied to the others.
+// Pipeline uses the first cache only.
+// It panics if no cache is given.
+func NewFallback(caches ...Cache) Cache {
+	if len(caches) == 0 {
+		panic("caches: NewFallback requires at least one cache")
+	}
+	return &fallbackCache{
+		Cache:  caches[0],
+		caches: caches,
+	}
+}
@@ -0,0 +1,32 @@
+package caches
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// GeoAdd stores member at the given longitude and latitude in the Redis geo set at key,
+// updating its position if it already exists.
+// Returns an error if the coordinates are out of range or the command fails.
+func (r *redisCache) GeoAdd(ctx context.Context, key string, lon, lat float64, member string) (err error) {
+	return r.client.GeoAdd(ctx, key, &redis.GeoLocation{
+		Name:      member,
+		Longitude: lon,
+		Latitude:  lat,
+	}).Err()
+}
+
+// GeoRadius returns the members of the Redis geo set at key that lie within radiusKm kilometers
+// of the given longitude and latitude, nearest first. It uses GEOSEARCH, which supersedes the
+// deprecated GEORADIUS command.
+// Returns an empty result for a missing key, or an error if the command fails.
+func (r *redisCache) GeoRadius(ctx context.Context, key string, lon, lat, radiusKm float64) (members []string, err error) {
+	return r.client.GeoSearch(ctx, key, &redis.GeoSearchQuery{
+		Longitude:  lon,
+		Latitude:   lat,
+		Radius:     radiusKm,
+		RadiusUnit: "km",
+		Sort:       "ASC",
+	}).Result()
+}
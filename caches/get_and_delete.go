@@ -0,0 +1,51 @@
+package caches
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/redis/go-redis/v9"
+)
+
+// GetAndDelete atomically retrieves and removes a single data record from Redis using GETDEL.
+// The data is decoded with the configured codec into a SingleDataRecord.
+// Returns ErrNotFound if the key does not exist, or an error if retrieval or decoding fails.
+func (r *redisCache) GetAndDelete(ctx context.Context, key string) (result SingleDataRecord, err error) {
+	resultStr, err := r.client.GetDel(ctx, key).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	err = decode(r.opts.codec, key, []byte(resultStr), &result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetAndDelete retrieves and removes a single data record from Memcache.
+// Memcache has no atomic read-and-delete, so this is emulated with a Get followed by a Delete.
+// Two concurrent callers may both read the value; only the one whose Delete succeeds gets it back,
+// the other receives ErrNotFound.
+// Returns the raw byte data from the cache, ErrNotFound if the key does not exist,
+// or an error if retrieval or deletion fails.
+func (m *memcacheCache) GetAndDelete(ctx context.Context, key string) (result SingleDataRecord, err error) {
+	resp, err := m.client.Get(key)
+	if err != nil {
+		if errors.Is(err, memcache.ErrCacheMiss) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if err = m.client.Delete(key); err != nil {
+		if errors.Is(err, memcache.ErrCacheMiss) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return resp.Value, nil
+}
@@ -0,0 +1,129 @@
+package caches
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// manyGetter is implemented by backends that can read several keys in one round trip.
+type manyGetter interface {
+	// getOrdered returns the values aligned to keys, with found[i] reporting whether keys[i] exists.
+	getOrdered(ctx context.Context, keys []string) (values []SingleDataRecord, found []bool, err error)
+}
+
+// GetMany retrieves the values of several keys from the cache. Keys that do not exist are left out of the result.
+// The Redis backend reads all keys with a single MGET; other caches, including decorators, read them one by one.
+// Returns an error if a read fails for any reason other than a missing key.
+func GetMany(ctx context.Context, cache Cache, keys []string) (found map[string]SingleDataRecord, err error) {
+	values, exists, err := getOrdered(ctx, cache, keys)
+	if err != nil {
+		return nil, err
+	}
+	found = make(map[string]SingleDataRecord, len(keys))
+	for i, key := range keys {
+		if exists[i] {
+			found[key] = values[i]
+		}
+	}
+	return found, nil
+}
+
+// GetOrdered retrieves the values of several keys as a slice aligned to keys, so values[i] holds the value of keys[i].
+// Keys that do not exist leave a nil entry; a key storing a JSON null is indistinguishable from a missing one,
+// so use GetMany when that matters. The Redis backend reads all keys with a single MGET, which preserves the key order;
+// other caches, including decorators, read them one by one.
+// Returns an error if a read fails for any reason other than a missing key.
+func GetOrdered(ctx context.Context, cache Cache, keys []string) (values []SingleDataRecord, err error) {
+	values, _, err = getOrdered(ctx, cache, keys)
+	return values, err
+}
+
+// getOrdered reads the keys with the backend's bulk read if it has one, and one by one otherwise.
+func getOrdered(ctx context.Context, cache Cache, keys []string) (values []SingleDataRecord, found []bool, err error) {
+	if getter, ok := cache.(manyGetter); ok {
+		return getter.getOrdered(ctx, keys)
+	}
+	values = make([]SingleDataRecord, len(keys))
+	found = make([]bool, len(keys))
+	for i, key := range keys {
+		if values[i], found[i], err = cache.Lookup(ctx, key); err != nil {
+			return nil, nil, err
+		}
+	}
+	return values, found, nil
+}
+
+// GetManyOrLoad retrieves the values of several keys, calling loader once with only the keys that were missing,
+// e.g. to fetch them from the database in bulk. Loaded values are cached with the TTL, best-effort,
+// and merged into the result. Keys the loader does not return are left out of the result.
+// Returns an error if reading the cache or the loader fails.
+func GetManyOrLoad(
+	ctx context.Context,
+	cache Cache,
+	keys []string,
+	ttl time.Duration,
+	loader func(ctx context.Context, missing []string) (map[string]SingleDataRecord, error),
+) (values map[string]SingleDataRecord, err error) {
+	values, err = GetMany(ctx, cache, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	seen := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		if _, ok := values[key]; ok {
+			continue
+		}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		missing = append(missing, key)
+	}
+	if len(missing) == 0 {
+		return values, nil
+	}
+
+	loaded, err := loader(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range missing {
+		value, ok := loaded[key]
+		if !ok {
+			continue
+		}
+		_ = cache.SetSingleWithTTL(ctx, key, value, ttl)
+		values[key] = value
+	}
+	return values, nil
+}
+
+// getOrdered reads the keys from Redis with a single MGET and decodes the values with the configured codec.
+// MGET replies in key order with nil for missing keys.
+func (r *redisCache) getOrdered(ctx context.Context, keys []string) (values []SingleDataRecord, found []bool, err error) {
+	values = make([]SingleDataRecord, len(keys))
+	found = make([]bool, len(keys))
+	if len(keys) == 0 {
+		return values, found, nil
+	}
+	replies, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, nil, err
+	}
+	for i, reply := range replies {
+		str, ok := reply.(string)
+		if !ok {
+			continue
+		}
+		if err = decode(r.opts.codec, keys[i], []byte(str), &values[i]); err != nil {
+			return nil, nil, err
+		}
+		found[i] = true
+	}
+	return values, found, nil
+}
@@ -0,0 +1,179 @@
+package caches
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+const (
+	// loadLockSuffix names the key that GetOrSetDistributed locks while loading a cold key.
+	loadLockSuffix = ":load-lock"
+	// loadLockPollInterval is how often instances waiting on another instance's load check for the value.
+	loadLockPollInterval = 50 * time.Millisecond
+)
+
+// deleteIfOwnerScript deletes KEYS[1] only when it holds ARGV[1].
+const deleteIfOwnerScript = `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`
+
+var (
+	_ ownerDeleter = &redisCache{}
+	_ ownerDeleter = &memcacheCache{}
+	_ ownerDeleter = &inMemoryCache{}
+)
+
+// ownerDeleter is implemented by backends that can delete a key only while it holds a token, atomically.
+type ownerDeleter interface {
+	// deleteIfOwner deletes key if it still holds token, reporting whether it did.
+	deleteIfOwner(ctx context.Context, key, token string) (deleted bool, err error)
+}
+
+// GetOrSetDistributed returns the value of key, loading and caching it with ttl on a miss,
+// while ensuring that across every instance sharing the cache only one runs loader for a cold key.
+// The instance that claims the key's load lock, "<key>:load-lock", taken with SetNX, loads and caches the value;
+// the others poll the cache until the value appears. If it has not appeared within lockWait,
+// for example because the loading instance crashed, they stop waiting and load it themselves.
+// The lock expires after lockWait, so a crashed loader does not block the key. It is released only while it
+// still holds this instance's random token, so a loader outliving lockWait never releases a lock claimed since by another instance.
+// Returns an error if lockWait is not positive, since the lock would never expire, or the loader's error
+// if loading fails; a failure to cache the loaded value is ignored, since the value itself is still valid.
+func GetOrSetDistributed(
+	ctx context.Context,
+	cache Cache,
+	key string,
+	ttl, lockWait time.Duration,
+	loader func(ctx context.Context) (SingleDataRecord, error),
+) (value SingleDataRecord, err error) {
+	if lockWait <= 0 {
+		return nil, fmt.Errorf("cache: load lock wait must be positive, got %s", lockWait)
+	}
+	value, found, err := cache.Lookup(ctx, key)
+	if err != nil || found {
+		return value, err
+	}
+
+	lockKey := key + loadLockSuffix
+	token := make([]byte, 16)
+	if _, err = rand.Read(token); err != nil {
+		return nil, err
+	}
+	owner := hex.EncodeToString(token)
+	locked, err := cache.SetNX(ctx, lockKey, owner, lockWait)
+	if err != nil {
+		return nil, err
+	}
+	if locked {
+		defer func() { _, _ = deleteIfOwner(context.Background(), cache, lockKey, owner) }()
+		return loadAndSet(ctx, cache, key, ttl, loader)
+	}
+
+	deadline := time.NewTimer(lockWait)
+	defer deadline.Stop()
+	ticker := time.NewTicker(loadLockPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-deadline.C:
+			return loadAndSet(ctx, cache, key, ttl, loader)
+		case <-ticker.C:
+		}
+		if value, found, err = cache.Lookup(ctx, key); err != nil || found {
+			return value, err
+		}
+	}
+}
+
+// loadAndSet runs loader and caches its value with ttl, best-effort.
+func loadAndSet(ctx context.Context, cache Cache, key string, ttl time.Duration, loader func(ctx context.Context) (SingleDataRecord, error)) (value SingleDataRecord, err error) {
+	value, err = loader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_ = cache.SetSingleWithTTL(ctx, key, value, ttl)
+	return value, nil
+}
+
+// deleteIfOwner deletes key only if it still holds token, as stored through SetNX, reporting whether it did.
+// Backends implementing ownerDeleter compare and delete atomically; for any other cache, such as a decorator,
+// the value is read and then deleted, which can still race with a concurrent claim.
+func deleteIfOwner(ctx context.Context, cache Cache, key, token string) (deleted bool, err error) {
+	if deleter, ok := cache.(ownerDeleter); ok {
+		return deleter.deleteIfOwner(ctx, key, token)
+	}
+	value, found, err := cache.Lookup(ctx, key)
+	if err != nil || !found || value != token {
+		return false, err
+	}
+	return true, cache.Delete(ctx, key)
+}
+
+// deleteIfOwner deletes the key with a Lua script only if it holds the codec-encoded token.
+func (r *redisCache) deleteIfOwner(ctx context.Context, key, token string) (deleted bool, err error) {
+	encoded, err := r.opts.codec.Marshal(token)
+	if err != nil {
+		return false, err
+	}
+	result, err := r.runScript(ctx, deleteIfOwnerScript, []string{key}, encoded)
+	if err != nil {
+		return false, err
+	}
+	return result == int64(1), nil
+}
+
+// deleteIfOwner expires the key through a CAS update only if it holds the codec-encoded token,
+// as Memcache has no conditional delete.
+func (m *memcacheCache) deleteIfOwner(ctx context.Context, key, token string) (deleted bool, err error) {
+	encoded, err := m.opts.codec.Marshal(token)
+	if err != nil {
+		return false, err
+	}
+	item, err := m.client.Get(key)
+	if err != nil {
+		if errors.Is(err, memcache.ErrCacheMiss) {
+			return false, nil
+		}
+		return false, err
+	}
+	if !bytes.Equal(item.Value, encoded) {
+		return false, nil
+	}
+	// A negative expiration makes the item expire immediately.
+	item.Expiration = -1
+	err = m.client.CompareAndSwap(item)
+	if err != nil {
+		if errors.Is(err, memcache.ErrCASConflict) || errors.Is(err, memcache.ErrNotStored) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// deleteIfOwner deletes the key under the lock only if it holds the codec-encoded token.
+func (m *inMemoryCache) deleteIfOwner(ctx context.Context, key, token string) (deleted bool, err error) {
+	encoded, err := m.opts.codec.Marshal(token)
+	if err != nil {
+		return false, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, err := m.getLocked(key)
+	if err != nil || !bytes.Equal(value, encoded) {
+		return false, nil
+	}
+	m.removeElement(m.entries[key])
+	return true, nil
+}
@@ -0,0 +1,186 @@
+package caches
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+var (
+	_ Cache    = &hashedKeyCache{}
+	_ Pipeline = &hashedKeyPipeline{}
+)
+
+type (
+	// hashedKeyCache transforms every key with hash before passing it to the wrapped cache,
+	// so raw identifiers are never stored as keys.
+	hashedKeyCache struct {
+		Cache
+		hash func(key string) string
+	}
+
+	// hashedKeyPipeline hashes the keys of queued commands and reports results under the original keys.
+	hashedKeyPipeline struct {
+		pipeline Pipeline
+		hash     func(key string) string
+		keys     []string // original keys, in queue order
+	}
+)
+
+// keyTransformer is implemented by decorators that store values under a transformed key.
+type keyTransformer interface {
+	storageKey(key string) string
+}
+
+// storageKey returns the key under which cache stores values for key, applying the
+// transforms of every key-transforming decorator in the chain.
+func storageKey(cache Cache, key string) string {
+	if transformer, ok := cache.(keyTransformer); ok {
+		return transformer.storageKey(key)
+	}
+	return key
+}
+
+// storageKey returns the hashed key, as transformed further by the wrapped cache.
+func (h *hashedKeyCache) storageKey(key string) string {
+	return storageKey(h.Cache, h.hash(key))
+}
+
+// SHA256Key hashes the key with SHA-256 and returns it hex encoded. It is the default hasher of NewHashedKeys.
+func SHA256Key(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// SetSingle stores the value under the hashed key.
+func (h *hashedKeyCache) SetSingle(ctx context.Context, key string, value SingleDataRecord) (err error) {
+	return h.Cache.SetSingle(ctx, h.hash(key), value)
+}
+
+// GetSingle retrieves the value stored under the hashed key.
+func (h *hashedKeyCache) GetSingle(ctx context.Context, key string) (result SingleDataRecord, err error) {
+	return h.Cache.GetSingle(ctx, h.hash(key))
+}
+
+// Lookup retrieves the value stored under the hashed key, reporting a miss through found.
+func (h *hashedKeyCache) Lookup(ctx context.Context, key string) (value SingleDataRecord, found bool, err error) {
+	return h.Cache.Lookup(ctx, h.hash(key))
+}
+
+// SetSingleWithTTL stores the value under the hashed key with the TTL.
+func (h *hashedKeyCache) SetSingleWithTTL(ctx context.Context, key string, value SingleDataRecord, ttl time.Duration) (err error) {
+	return h.Cache.SetSingleWithTTL(ctx, h.hash(key), value, ttl)
+}
+
+// SetNX stores the value under the hashed key if it does not exist yet.
+func (h *hashedKeyCache) SetNX(ctx context.Context, key string, value SingleDataRecord, ttl time.Duration) (stored bool, err error) {
+	return h.Cache.SetNX(ctx, h.hash(key), value, ttl)
+}
+
+// SetMultiple stores the values under the hashed key.
+func (h *hashedKeyCache) SetMultiple(ctx context.Context, key string, value MultipleDataRecord) (err error) {
+	return h.Cache.SetMultiple(ctx, h.hash(key), value)
+}
+
+// GetMultiple retrieves the values stored under the hashed key.
+func (h *hashedKeyCache) GetMultiple(ctx context.Context, key string) (result MultipleDataRecord, err error) {
+	return h.Cache.GetMultiple(ctx, h.hash(key))
+}
+
+// GetAndDelete retrieves and removes the value stored under the hashed key.
+func (h *hashedKeyCache) GetAndDelete(ctx context.Context, key string) (result SingleDataRecord, err error) {
+	return h.Cache.GetAndDelete(ctx, h.hash(key))
+}
+
+// ImportJSONL imports the records with their keys hashed.
+func (h *hashedKeyCache) ImportJSONL(ctx context.Context, r io.Reader, ttl time.Duration) (count int, err error) {
+	pr, pw := io.Pipe()
+	defer pr.Close()
+	go func() {
+		encoder := json.NewEncoder(pw)
+		_, err := readJSONL(r, func(record *jsonlRecord) error {
+			record.Key = h.hash(record.Key)
+			return encoder.Encode(record)
+		})
+		pw.CloseWithError(err)
+	}()
+	return h.Cache.ImportJSONL(ctx, pr, ttl)
+}
+
+// Delete removes the hashed key.
+func (h *hashedKeyCache) Delete(ctx context.Context, key string) (err error) {
+	return h.Cache.Delete(ctx, h.hash(key))
+}
+
+// Pipeline returns a builder that hashes the keys of queued commands.
+// Results are reported under the original keys.
+func (h *hashedKeyCache) Pipeline() (pipeline Pipeline) {
+	return &hashedKeyPipeline{pipeline: h.Cache.Pipeline(), hash: h.hash}
+}
+
+// GetMultipleInto retrieves and decodes the values stored under the hashed key.
+func (h *hashedKeyCache) GetMultipleInto(ctx context.Context, key string, newItem func() interface{}) (result MultipleDataRecord, err error) {
+	return h.Cache.GetMultipleInto(ctx, h.hash(key), newItem)
+}
+
+// SetMultipleIndexed stores each element under the hash of the key derived by keyFunc.
+func (h *hashedKeyCache) SetMultipleIndexed(ctx context.Context, keyFunc func(item interface{}) string, values MultipleDataRecord, ttl time.Duration) (err error) {
+	return h.Cache.SetMultipleIndexed(ctx, func(item interface{}) string {
+		return h.hash(keyFunc(item))
+	}, values, ttl)
+}
+
+// MergePatch patches the document stored under the hashed key.
+func (h *hashedKeyCache) MergePatch(ctx context.Context, key string, patch json.RawMessage, ttl time.Duration) (err error) {
+	return h.Cache.MergePatch(ctx, h.hash(key), patch, ttl)
+}
+
+// Set queues storing the value under the hashed key.
+func (p *hashedKeyPipeline) Set(key string, value SingleDataRecord, ttl time.Duration) Pipeline {
+	p.keys = append(p.keys, key)
+	p.pipeline.Set(p.hash(key), value, ttl)
+	return p
+}
+
+// Get queues retrieving the value stored under the hashed key.
+func (p *hashedKeyPipeline) Get(key string) Pipeline {
+	p.keys = append(p.keys, key)
+	p.pipeline.Get(p.hash(key))
+	return p
+}
+
+// Delete queues removing the hashed key.
+func (p *hashedKeyPipeline) Delete(key string) Pipeline {
+	p.keys = append(p.keys, key)
+	p.pipeline.Delete(p.hash(key))
+	return p
+}
+
+// Exec runs the queued commands and reports each result under its original key.
+func (p *hashedKeyPipeline) Exec(ctx context.Context) (results []Result, err error) {
+	results, err = p.pipeline.Exec(ctx)
+	for i := range results {
+		if i < len(p.keys) {
+			results[i].Key = p.keys[i]
+		}
+	}
+	p.keys = nil
+	return results, err
+}
+
+// NewHashedKeys wraps the cache so every key is transformed with hasher before it reaches the backend,
+// keeping raw identifiers such as user IDs out of the store and keys within memcache's length limit.
+// Callers keep using plaintext keys. A nil hasher uses SHA256Key.
+// Keys returned by the backend itself, such as from Redis Scan, are the hashed ones.
+func NewHashedKeys(cache Cache, hasher func(key string) string) Cache {
+	if hasher == nil {
+		hasher = SHA256Key
+	}
+	return &hashedKeyCache{
+		Cache: cache,
+		hash:  hasher,
+	}
+}
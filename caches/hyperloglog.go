@@ -0,0 +1,24 @@
+package caches
+
+import "context"
+
+// PFAdd adds elements to the Redis HyperLogLog stored at key, creating it if needed.
+// Adding no elements is a no-op.
+// Returns an error if the command fails.
+func (r *redisCache) PFAdd(ctx context.Context, key string, elements ...string) (err error) {
+	if len(elements) == 0 {
+		return nil
+	}
+	args := make([]interface{}, len(elements))
+	for i, element := range elements {
+		args[i] = element
+	}
+	return r.client.PFAdd(ctx, key, args...).Err()
+}
+
+// PFCount returns the approximate number of distinct elements added to the Redis HyperLogLog at key,
+// with a standard error of 0.81%, or 0 for a missing key.
+// Returns an error if the command fails.
+func (r *redisCache) PFCount(ctx context.Context, key string) (count int64, err error) {
+	return r.client.PFCount(ctx, key).Result()
+}
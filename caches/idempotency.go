@@ -0,0 +1,335 @@
+package caches
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/redis/go-redis/v9"
+)
+
+// Idempotency record states.
+const (
+	idempotencyPending   = "pending"
+	idempotencyCompleted = "completed"
+)
+
+const (
+	// maxClaimAttempts bounds how often Begin retries claiming a key that expired while it was being read.
+	maxClaimAttempts = 3
+	// claimSweepInterval is how often Begin forgets expired claims that were never completed.
+	claimSweepInterval = time.Minute
+)
+
+var (
+	// ErrRequestInProgress is returned by Begin when another request holds the key but has not completed yet.
+	ErrRequestInProgress = errors.New("cache: idempotent request already in progress")
+	// ErrIdempotencyKeyExpired is returned by Complete when the key's claim expired before completion.
+	ErrIdempotencyKeyExpired = errors.New("cache: idempotency key expired before completion")
+)
+
+var (
+	_ conditionalSetter = &redisCache{}
+	_ conditionalSetter = &memcacheCache{}
+	_ conditionalSetter = &inMemoryCache{}
+)
+
+type (
+	// IdempotencyStore records which request keys have been processed and their responses,
+	// giving at-most-once processing of requests that share an idempotency key.
+	IdempotencyStore struct {
+		cache Cache
+
+		mu        sync.Mutex
+		claims    map[string]idempotencyClaim // claims made by Begin awaiting Complete, by key
+		lastSweep time.Time
+	}
+
+	// idempotencyClaim identifies a claim made by Begin, so Complete only writes over its own pending record.
+	idempotencyClaim struct {
+		token     string
+		expiresAt time.Time // zero means no expiration
+	}
+
+	// idempotencyRecord is the value stored under an idempotency key.
+	idempotencyRecord struct {
+		Status    string `json:"status" msgpack:"status"`
+		Token     string `json:"token,omitempty" msgpack:"token,omitempty"` // identifies the claim made by Begin
+		ExpiresAt int64  `json:"expires_at" msgpack:"expires_at"`           // unix milliseconds, 0 means no expiration
+		Response  []byte `json:"response,omitempty" msgpack:"response,omitempty"`
+	}
+
+	// conditionalSetter is implemented by backends that can replace a value depending on its current one, atomically.
+	conditionalSetter interface {
+		// setIf stores value under key with ttl only if the key exists and match accepts its current value,
+		// which match reads by decoding it into a target with the backend's codec.
+		setIf(ctx context.Context, key string, match func(decode func(target interface{}) error) bool, value interface{}, ttl time.Duration) (stored bool, err error)
+	}
+
+	// codecHolder is implemented by backends to expose the codec their stored bytes are encoded with.
+	codecHolder interface {
+		codec() Codec
+	}
+)
+
+// decodeIdempotencyRecord converts a value read from the cache back into an idempotencyRecord.
+// Backends return either the raw stored bytes, decoded here with the backend's codec, or a generic decoded value.
+func decodeIdempotencyRecord(cache Cache, value SingleDataRecord) (record *idempotencyRecord, err error) {
+	record = &idempotencyRecord{}
+	if data, ok := value.([]byte); ok {
+		var codec Codec = JSONCodec{}
+		if holder, ok := cache.(codecHolder); ok {
+			codec = holder.codec()
+		}
+		if err = codec.Unmarshal(data, record); err != nil {
+			return nil, err
+		}
+		return record, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Begin claims the idempotency key for the current request for the given TTL using SetNX.
+// If the key was free, fresh is true and the caller should process the request and then call Complete.
+// If the key was already completed, fresh is false and stored holds the recorded response.
+// The claim is remembered by the store, so Complete on the same store only writes over this claim.
+// If the key expires between the failed claim and reading it, claiming is retried.
+// Returns ErrRequestInProgress if another request claimed the key but has not completed it yet,
+// or an error if the cache operation fails.
+func (s *IdempotencyStore) Begin(ctx context.Context, key string, ttl time.Duration) (stored []byte, fresh bool, err error) {
+	random := make([]byte, 16)
+	if _, err = rand.Read(random); err != nil {
+		return nil, false, err
+	}
+	pending := &idempotencyRecord{Status: idempotencyPending, Token: hex.EncodeToString(random)}
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+		pending.ExpiresAt = expiresAt.UnixMilli()
+	}
+
+	for attempt := 0; attempt < maxClaimAttempts; attempt++ {
+		fresh, err = s.cache.SetNX(ctx, key, pending, ttl)
+		if err != nil {
+			return nil, false, err
+		}
+		if fresh {
+			s.remember(key, idempotencyClaim{token: pending.Token, expiresAt: expiresAt})
+			return nil, true, nil
+		}
+
+		value, err := s.cache.GetSingle(ctx, key)
+		if isNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		record, err := decodeIdempotencyRecord(s.cache, value)
+		if err != nil {
+			return nil, false, err
+		}
+		if record.Status != idempotencyCompleted {
+			return nil, false, ErrRequestInProgress
+		}
+		return record.Response, false, nil
+	}
+	return nil, false, ErrRequestInProgress
+}
+
+// remember records the claim made on key, forgetting claims that expired without being completed.
+func (s *IdempotencyStore) remember(key string, claim idempotencyClaim) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.claims == nil {
+		s.claims = make(map[string]idempotencyClaim)
+	}
+	if now := time.Now(); now.Sub(s.lastSweep) >= claimSweepInterval {
+		for k, c := range s.claims {
+			if !c.expiresAt.IsZero() && now.After(c.expiresAt) {
+				delete(s.claims, k)
+			}
+		}
+		s.lastSweep = now
+	}
+	s.claims[key] = claim
+}
+
+// takeClaim removes and returns the claim this store made on key.
+func (s *IdempotencyStore) takeClaim(key string) (token string, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	claim, ok := s.claims[key]
+	delete(s.claims, key)
+	return claim.token, ok
+}
+
+// Complete stores the response for a key claimed with Begin on this store, so duplicates receive it.
+// The key keeps the expiration set by Begin. The response is only written while the key still holds
+// the pending claim made by Begin, so a request whose claim expired and was taken over by another
+// never overwrites the new claim. Backends other than Redis, Memcache and in-memory, such as decorators,
+// check the claim and write in separate operations.
+// Returns ErrIdempotencyKeyExpired if the key was not claimed through this store, or its claim expired
+// or was taken over in the meantime, or an error if the cache operation fails.
+func (s *IdempotencyStore) Complete(ctx context.Context, key string, response []byte) (err error) {
+	token, ok := s.takeClaim(key)
+	if !ok {
+		return ErrIdempotencyKeyExpired
+	}
+	value, err := s.cache.GetSingle(ctx, key)
+	if err != nil {
+		if isNotFound(err) {
+			return ErrIdempotencyKeyExpired
+		}
+		return err
+	}
+	record, err := decodeIdempotencyRecord(s.cache, value)
+	if err != nil {
+		return err
+	}
+	if !record.claimedBy(token) {
+		return ErrIdempotencyKeyExpired
+	}
+
+	var ttl time.Duration
+	if record.ExpiresAt > 0 {
+		ttl = time.Until(time.UnixMilli(record.ExpiresAt))
+		if ttl <= 0 {
+			return ErrIdempotencyKeyExpired
+		}
+	}
+	record.Status = idempotencyCompleted
+	record.Response = response
+
+	setter, ok := s.cache.(conditionalSetter)
+	if !ok {
+		return s.cache.SetSingleWithTTL(ctx, key, record, ttl)
+	}
+	stored, err := setter.setIf(ctx, key, func(decode func(target interface{}) error) bool {
+		current := &idempotencyRecord{}
+		return decode(current) == nil && current.claimedBy(token)
+	}, record, ttl)
+	if err != nil {
+		return err
+	}
+	if !stored {
+		return ErrIdempotencyKeyExpired
+	}
+	return nil
+}
+
+// claimedBy reports whether the record is still the pending claim identified by token.
+func (r *idempotencyRecord) claimedBy(token string) bool {
+	return r.Status == idempotencyPending && r.Token == token
+}
+
+// setIf stores the value in a WATCH transaction if match accepts the current value.
+func (r *redisCache) setIf(ctx context.Context, key string, match func(decode func(target interface{}) error) bool, value interface{}, ttl time.Duration) (stored bool, err error) {
+	data, err := r.opts.codec.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	err = r.client.Watch(ctx, func(tx *redis.Tx) error {
+		current, err := tx.Get(ctx, key).Bytes()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				return nil
+			}
+			return err
+		}
+		if !match(func(target interface{}) error { return decode(r.opts.codec, key, current, target) }) {
+			return nil
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, data, ttl)
+			return nil
+		})
+		stored = err == nil
+		return err
+	}, key)
+	if errors.Is(err, redis.TxFailedErr) {
+		return false, nil
+	}
+	return stored, err
+}
+
+// setIf stores the value with a CAS update if match accepts the current value.
+func (m *memcacheCache) setIf(ctx context.Context, key string, match func(decode func(target interface{}) error) bool, value interface{}, ttl time.Duration) (stored bool, err error) {
+	data, err := m.opts.codec.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	item, err := m.client.Get(key)
+	if err != nil {
+		if errors.Is(err, memcache.ErrCacheMiss) {
+			return false, nil
+		}
+		return false, err
+	}
+	if !match(func(target interface{}) error { return decode(m.opts.codec, key, item.Value, target) }) {
+		return false, nil
+	}
+	item.Value = data
+	item.Expiration = memcacheExpiration(ttl)
+	if err = m.client.CompareAndSwap(item); err != nil {
+		if errors.Is(err, memcache.ErrCASConflict) || errors.Is(err, memcache.ErrNotStored) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// setIf stores the value under the lock if match accepts the current value.
+func (m *inMemoryCache) setIf(ctx context.Context, key string, match func(decode func(target interface{}) error) bool, value interface{}, ttl time.Duration) (stored bool, err error) {
+	data, err := m.opts.codec.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	current, err := m.getLocked(key)
+	if err != nil {
+		return false, nil
+	}
+	if !match(func(target interface{}) error { return decode(m.opts.codec, key, current, target) }) {
+		return false, nil
+	}
+	m.store(key, data, ttl)
+	return true, nil
+}
+
+// codec returns the codec values are stored with.
+func (r *redisCache) codec() Codec {
+	return r.opts.codec
+}
+
+// codec returns the codec values are stored with.
+func (m *memcacheCache) codec() Codec {
+	return m.opts.codec
+}
+
+// codec returns the codec values are stored with.
+func (m *inMemoryCache) codec() Codec {
+	return m.opts.codec
+}
+
+// NewIdempotencyStore creates an IdempotencyStore on top of the given Cache.
+func NewIdempotencyStore(cache Cache) *IdempotencyStore {
+	return &IdempotencyStore{
+		cache: cache,
+	}
+}
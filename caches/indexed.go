@@ -0,0 +1,55 @@
+package caches
+
+import (
+	"context"
+	"time"
+)
+
+// SetMultipleIndexed stores each element of values in Redis under the key returned by keyFunc,
+// so individual elements can later be fetched with GetSingle.
+// Every element is validated and encoded before anything is sent, then all writes go out in one pipeline
+// with the given TTL (0 means no expiration).
+// Returns an error if validation, encoding or any write fails.
+func (r *redisCache) SetMultipleIndexed(ctx context.Context, keyFunc func(item interface{}) string, values MultipleDataRecord, ttl time.Duration) (err error) {
+	encoded := make([][]byte, len(values))
+	for i, value := range values {
+		if err = r.opts.validate(value); err != nil {
+			return err
+		}
+		if encoded[i], err = r.opts.codec.Marshal(value); err != nil {
+			return err
+		}
+	}
+
+	pipe := r.client.Pipeline()
+	for i, value := range values {
+		pipe.Set(ctx, keyFunc(value), encoded[i], r.opts.jitter(ttl))
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// SetMultipleIndexed stores each element of values in Memcache under the key returned by keyFunc,
+// so individual elements can later be fetched with GetSingle.
+// Elements are written one by one with the given TTL (0 means no expiration).
+// Returns an error if validation, encoding or a write fails; earlier elements stay stored.
+func (m *memcacheCache) SetMultipleIndexed(ctx context.Context, keyFunc func(item interface{}) string, values MultipleDataRecord, ttl time.Duration) (err error) {
+	for _, value := range values {
+		if err = m.SetSingleWithTTL(ctx, keyFunc(value), value, ttl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetMultipleIndexed stores each element of values in memory under the key returned by keyFunc,
+// so individual elements can later be fetched with GetSingle.
+// Returns an error if validation or encoding fails; earlier elements stay stored.
+func (m *inMemoryCache) SetMultipleIndexed(ctx context.Context, keyFunc func(item interface{}) string, values MultipleDataRecord, ttl time.Duration) (err error) {
+	for _, value := range values {
+		if err = m.SetSingleWithTTL(ctx, keyFunc(value), value, ttl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package caches
+
+import (
+	"context"
+	"time"
+)
+
+var _ Cache = &instrumentedCache{}
+
+type (
+	// Metrics receives the measurements of an instrumented cache, e.g. to feed Prometheus counters and histograms.
+	// Implementations must be safe for concurrent use.
+	Metrics interface {
+		// CountHit records a read that found its key.
+		CountHit(operation string)
+		// CountMiss records a read whose key did not exist.
+		CountMiss(operation string)
+		// ObserveValueSize records the encoded size in bytes of a value written or read by the operation.
+		ObserveValueSize(operation string, bytes int)
+	}
+
+	// instrumentedCache reports hits, misses and value sizes of the wrapped cache to metrics.
+	// Operations it does not override are passed through unmeasured.
+	instrumentedCache struct {
+		Cache
+		metrics Metrics
+		codec   Codec // encodes values to measure them
+	}
+)
+
+// observeSize records the encoded size of value for the operation.
+// Raw byte values, as returned by the Memcache backend, are measured as-is.
+// Values that cannot be encoded are not recorded; the backend reports the encoding error itself.
+func (i *instrumentedCache) observeSize(operation string, value interface{}) {
+	if data, ok := value.([]byte); ok {
+		i.metrics.ObserveValueSize(operation, len(data))
+		return
+	}
+	if data, err := i.codec.Marshal(value); err == nil {
+		i.metrics.ObserveValueSize(operation, len(data))
+	}
+}
+
+// observeRead records the outcome of a read: a hit with the value size, or a miss.
+func (i *instrumentedCache) observeRead(operation string, value interface{}, err error) {
+	if err != nil {
+		if isNotFound(err) {
+			i.metrics.CountMiss(operation)
+		}
+		return
+	}
+	i.metrics.CountHit(operation)
+	i.observeSize(operation, value)
+}
+
+// SetSingle stores the value and records its size.
+func (i *instrumentedCache) SetSingle(ctx context.Context, key string, value SingleDataRecord) (err error) {
+	if err = i.Cache.SetSingle(ctx, key, value); err == nil {
+		i.observeSize("SetSingle", value)
+	}
+	return err
+}
+
+// GetSingle reads the value and records a hit with its size, or a miss.
+func (i *instrumentedCache) GetSingle(ctx context.Context, key string) (result SingleDataRecord, err error) {
+	result, err = i.Cache.GetSingle(ctx, key)
+	i.observeRead("GetSingle", result, err)
+	return result, err
+}
+
+// Lookup reads the value and records a hit with its size, or a miss.
+func (i *instrumentedCache) Lookup(ctx context.Context, key string) (value SingleDataRecord, found bool, err error) {
+	return lookup(i.GetSingle(ctx, key))
+}
+
+// SetSingleWithTTL stores the value with the TTL and records its size.
+func (i *instrumentedCache) SetSingleWithTTL(ctx context.Context, key string, value SingleDataRecord, ttl time.Duration) (err error) {
+	if err = i.Cache.SetSingleWithTTL(ctx, key, value, ttl); err == nil {
+		i.observeSize("SetSingleWithTTL", value)
+	}
+	return err
+}
+
+// SetNX stores the value if the key is missing and records its size when stored.
+func (i *instrumentedCache) SetNX(ctx context.Context, key string, value SingleDataRecord, ttl time.Duration) (stored bool, err error) {
+	if stored, err = i.Cache.SetNX(ctx, key, value, ttl); err == nil && stored {
+		i.observeSize("SetNX", value)
+	}
+	return stored, err
+}
+
+// SetMultiple stores the values and records their size.
+func (i *instrumentedCache) SetMultiple(ctx context.Context, key string, value MultipleDataRecord) (err error) {
+	if err = i.Cache.SetMultiple(ctx, key, value); err == nil {
+		i.observeSize("SetMultiple", value)
+	}
+	return err
+}
+
+// GetMultiple reads the values and records a hit with their size, or a miss.
+func (i *instrumentedCache) GetMultiple(ctx context.Context, key string) (result MultipleDataRecord, err error) {
+	result, err = i.Cache.GetMultiple(ctx, key)
+	i.observeRead("GetMultiple", result, err)
+	return result, err
+}
+
+// GetAndDelete reads and removes the value and records a hit with its size, or a miss.
+func (i *instrumentedCache) GetAndDelete(ctx context.Context, key string) (result SingleDataRecord, err error) {
+	result, err = i.Cache.GetAndDelete(ctx, key)
+	i.observeRead("GetAndDelete", result, err)
+	return result, err
+}
+
+// NewInstrumented wraps the cache so reads report hits and misses, and reads and writes report
+// the encoded size of their values, labeled by operation, which shows which code paths store oversized blobs.
+// Sizes are measured by encoding the value once more after the operation succeeded, which adds its encoding cost.
+// When cache is a backend created by this package its own codec is used, so the sizes match what is stored;
+// otherwise the codec given through opts is used (JSONCodec by default), which should match the backend's.
+// It panics if metrics is nil.
+func NewInstrumented(cache Cache, metrics Metrics, opts ...Option) Cache {
+	if metrics == nil {
+		panic("caches: NewInstrumented requires non-nil metrics")
+	}
+	codec := newOptions(opts...).codec
+	if holder, ok := cache.(codecHolder); ok {
+		codec = holder.codec()
+	}
+	return &instrumentedCache{
+		Cache:   cache,
+		metrics: metrics,
+		codec:   codec,
+	}
+}
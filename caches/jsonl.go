@@ -0,0 +1,139 @@
+package caches
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+const (
+	// importBatchSize is the number of records written per Redis pipeline during ImportJSONL.
+	importBatchSize = 100
+	// maxJSONLLineSize is the longest JSONL line accepted by ImportJSONL.
+	maxJSONLLineSize = 16 * 1024 * 1024
+	// memcacheRelativeExpirationLimit is the longest expiration memcache treats as relative;
+	// longer values are interpreted as absolute unix timestamps.
+	memcacheRelativeExpirationLimit = 30 * 24 * time.Hour
+)
+
+// jsonlRecord is a single line of a JSONL cache import or export.
+type jsonlRecord struct {
+	Key   string           `json:"key"`
+	Value SingleDataRecord `json:"value"`
+}
+
+// readJSONL streams JSONL records from r, calling fn for each decoded record.
+// Blank lines are skipped. Returns the number of records passed to fn successfully,
+// or an error naming the offending line if decoding or fn fails.
+func readJSONL(r io.Reader, fn func(record *jsonlRecord) error) (count int, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxJSONLLineSize)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		record := &jsonlRecord{}
+		if err = json.Unmarshal(scanner.Bytes(), record); err != nil {
+			return count, fmt.Errorf("cache: decode jsonl line %d: %w", line, err)
+		}
+		if record.Key == "" {
+			return count, fmt.Errorf("cache: decode jsonl line %d: missing key", line)
+		}
+		if err = fn(record); err != nil {
+			return count, fmt.Errorf("cache: import jsonl line %d: %w", line, err)
+		}
+		count++
+	}
+	if err = scanner.Err(); err != nil {
+		return count, err
+	}
+	return count, nil
+}
+
+// ImportJSONL streams `{"key": ..., "value": ...}` records from r into Redis.
+// Each value is validated and encoded with the configured codec, then written with the given TTL (0 means no expiration).
+// Writes are pipelined in batches to keep large imports fast.
+// Returns the number of records imported, or an error if decoding, validation, encoding or storage fails.
+func (r *redisCache) ImportJSONL(ctx context.Context, reader io.Reader, ttl time.Duration) (count int, err error) {
+	pipe := r.client.Pipeline()
+	pending := 0
+	flush := func() error {
+		if pending == 0 {
+			return nil
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+		count += pending
+		pending = 0
+		return nil
+	}
+
+	_, err = readJSONL(reader, func(record *jsonlRecord) error {
+		if err := r.opts.validate(record.Value); err != nil {
+			return err
+		}
+		value, err := r.opts.codec.Marshal(record.Value)
+		if err != nil {
+			return err
+		}
+		pipe.Set(ctx, record.Key, value, r.opts.jitter(ttl))
+		pending++
+		if pending >= importBatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		pipe.Discard()
+		return count, err
+	}
+	if err = flush(); err != nil {
+		return count, err
+	}
+	return count, nil
+}
+
+// ImportJSONL streams `{"key": ..., "value": ...}` records from r into Memcache.
+// Each record is written with SetSingle semantics and the given TTL (0 means no expiration).
+// Returns the number of records imported, or an error if decoding, validation or storage fails.
+func (m *memcacheCache) ImportJSONL(ctx context.Context, reader io.Reader, ttl time.Duration) (count int, err error) {
+	return readJSONL(reader, func(record *jsonlRecord) error {
+		if err := m.opts.validate(record.Value); err != nil {
+			return err
+		}
+		value, err := m.opts.codec.Marshal(record.Value)
+		if err != nil {
+			return err
+		}
+		return m.client.Set(&memcache.Item{
+			Key:        record.Key,
+			Value:      value,
+			Expiration: memcacheExpiration(m.opts.jitter(ttl)),
+		})
+	})
+}
+
+// memcacheExpiration converts a TTL into a memcache expiration value.
+// TTLs beyond memcache's 30 day relative limit are sent as absolute unix timestamps.
+func memcacheExpiration(ttl time.Duration) int32 {
+	if ttl <= 0 {
+		return 0
+	}
+	if ttl > memcacheRelativeExpirationLimit {
+		return int32(time.Now().Add(ttl).Unix())
+	}
+	seconds := int32(ttl / time.Second)
+	if seconds == 0 {
+		seconds = 1
+	}
+	return seconds
+}
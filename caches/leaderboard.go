@@ -0,0 +1,47 @@
+package caches
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ScoredMember is a member of a leaderboard together with its score.
+type ScoredMember struct {
+	Member string  // The member name, e.g. a user ID
+	Score  float64 // The member's accumulated score
+}
+
+// IncrementScore adds delta to the score of member in the Redis sorted set at key,
+// creating the set and the member with a score of delta if needed. A negative delta lowers the score.
+// Returns the member's new score, or an error if the command fails.
+func (r *redisCache) IncrementScore(ctx context.Context, key, member string, delta float64) (score float64, err error) {
+	return r.client.ZIncrBy(ctx, key, delta, member).Result()
+}
+
+// TopN returns the n members with the highest scores in the Redis sorted set at key, highest first.
+// Members with equal scores are ordered by member name, descending. A missing key yields no members,
+// as does an n of 0 or less.
+// Returns an error if the command fails.
+func (r *redisCache) TopN(ctx context.Context, key string, n int) (members []ScoredMember, err error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	scored, err := r.client.ZRevRangeWithScores(ctx, key, 0, int64(n-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+	members = make([]ScoredMember, len(scored))
+	for i, z := range scored {
+		members[i] = ScoredMember{Member: memberString(z), Score: z.Score}
+	}
+	return members, nil
+}
+
+// memberString returns the sorted set member as a string; go-redis decodes members as strings.
+func memberString(z redis.Z) string {
+	if member, ok := z.Member.(string); ok {
+		return member
+	}
+	return ""
+}
@@ -0,0 +1,114 @@
+package caches
+
+import (
+	"context"
+	"errors"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// errListStorageRequired is returned by list operations when the Redis backend stores records as a single value.
+	errListStorageRequired = errors.New("cache: list operation requires WithListStorage")
+	// errInvalidPage is returned by GetMultiplePage for a negative offset or a limit below 1.
+	errInvalidPage = errors.New("cache: page offset must not be negative and limit must be positive")
+)
+
+// encodeElements encodes every element of values with the configured codec, for storage as list items.
+func (r *redisCache) encodeElements(values MultipleDataRecord) (encoded []interface{}, err error) {
+	encoded = make([]interface{}, len(values))
+	for i, value := range values {
+		if encoded[i], err = r.opts.codec.Marshal(value); err != nil {
+			return nil, err
+		}
+	}
+	return encoded, nil
+}
+
+// setList replaces the list stored at key with the values, atomically.
+// Redis does not keep empty lists, so storing no values deletes the key.
+func (r *redisCache) setList(ctx context.Context, key string, values MultipleDataRecord) (err error) {
+	encoded, err := r.encodeElements(values)
+	if err != nil {
+		return err
+	}
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		if len(encoded) > 0 {
+			pipe.RPush(ctx, key, encoded...)
+		}
+		return nil
+	})
+	return err
+}
+
+// getList reads every element of the list stored at key and decodes each with the configured codec.
+// Returns ErrNotFound if the key does not exist.
+func (r *redisCache) getList(ctx context.Context, key string) (result MultipleDataRecord, err error) {
+	items, err := r.client.LRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, ErrNotFound
+	}
+	return r.decodeItems(key, items)
+}
+
+// GetMultiplePage reads up to limit records starting at offset from the records stored at key with LRANGE,
+// so huge record lists can be paged through without loading them whole. Offsets count from 0;
+// a page past the end of the list is empty, and the last page may hold fewer than limit records.
+// It requires WithListStorage, since records stored as a single value can only be read whole.
+// Returns an error if list storage is not enabled or the page is invalid, ErrNotFound if the key does not exist,
+// or an error if the read or decoding fails.
+func (r *redisCache) GetMultiplePage(ctx context.Context, key string, offset, limit int64) (result MultipleDataRecord, err error) {
+	if !r.opts.listStorage {
+		return nil, errListStorageRequired
+	}
+	if offset < 0 || limit < 1 {
+		return nil, errInvalidPage
+	}
+	var items *redis.StringSliceCmd
+	var exists *redis.IntCmd
+	_, err = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		items = pipe.LRange(ctx, key, offset, offset+limit-1)
+		exists = pipe.Exists(ctx, key)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if exists.Val() == 0 {
+		return nil, ErrNotFound
+	}
+	return r.decodeItems(key, items.Val())
+}
+
+// decodeItems decodes the items of the list stored at key with the configured codec.
+func (r *redisCache) decodeItems(key string, items []string) (result MultipleDataRecord, err error) {
+	result = make(MultipleDataRecord, len(items))
+	for i, item := range items {
+		if err = decode(r.opts.codec, key, []byte(item), &result[i]); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// AppendMultiple appends the values to the end of the records stored at key with RPUSH,
+// creating the list if it does not exist. Only the appended elements are encoded and sent.
+// It requires WithListStorage, since records stored as a single value cannot be appended to.
+// Returns an error if list storage is not enabled, or if encoding or the append fails.
+func (r *redisCache) AppendMultiple(ctx context.Context, key string, values MultipleDataRecord) (err error) {
+	if !r.opts.listStorage {
+		return errListStorageRequired
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	encoded, err := r.encodeElements(values)
+	if err != nil {
+		return err
+	}
+	return r.client.RPush(ctx, key, encoded...).Err()
+}
@@ -0,0 +1,52 @@
+package caches
+
+import "context"
+
+// lookup converts the result of a GetSingle call into the comma-ok form:
+// a miss becomes (nil, false, nil) and only real failures keep their error.
+func lookup(result SingleDataRecord, err error) (value SingleDataRecord, found bool, _ error) {
+	if err != nil {
+		if isNotFound(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return result, true, nil
+}
+
+// Lookup retrieves a single data record from Redis, reporting a missing key through found instead of an error.
+func (r *redisCache) Lookup(ctx context.Context, key string) (value SingleDataRecord, found bool, err error) {
+	return lookup(r.GetSingle(ctx, key))
+}
+
+// Lookup retrieves a single data record from Memcache, reporting a missing key through found instead of an error.
+// As with GetSingle, the value is the raw byte data from the cache.
+func (m *memcacheCache) Lookup(ctx context.Context, key string) (value SingleDataRecord, found bool, err error) {
+	return lookup(m.GetSingle(ctx, key))
+}
+
+// Lookup retrieves a single data record from memory, reporting a missing key through found instead of an error.
+func (m *inMemoryCache) Lookup(ctx context.Context, key string) (value SingleDataRecord, found bool, err error) {
+	return lookup(m.GetSingle(ctx, key))
+}
+
+// Lookup returns the value from the first cache in the chain that has it,
+// reporting a miss in every cache through found instead of an error.
+func (f *fallbackCache) Lookup(ctx context.Context, key string) (value SingleDataRecord, found bool, err error) {
+	return lookup(f.GetSingle(ctx, key))
+}
+
+// Lookup retrieves the value through the recorder's GetSingle so the read is recorded.
+func (r *recordingCache) Lookup(ctx context.Context, key string) (value SingleDataRecord, found bool, err error) {
+	return lookup(r.GetSingle(ctx, key))
+}
+
+// Lookup replays the recorded GetSingle result for the key.
+func (r *replayCache) Lookup(ctx context.Context, key string) (value SingleDataRecord, found bool, err error) {
+	return lookup(r.GetSingle(ctx, key))
+}
+
+// Lookup retrieves the value from the wrapped cache, logging the call if it is slow.
+func (s *slowLogCache) Lookup(ctx context.Context, key string) (value SingleDataRecord, found bool, err error) {
+	return lookup(s.GetSingle(ctx, key))
+}
@@ -0,0 +1,290 @@
+package caches
+
+import (
+	"container/list"
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+var (
+	_ Cache         = &inMemoryCache{}
+	_ InMemoryCache = &inMemoryCache{}
+)
+
+type (
+	// InMemoryCache extends Cache with introspection of the in-process backend.
+	InMemoryCache interface {
+		Cache
+		SlidingCache
+		TTLCache
+
+		// Len returns the number of entries currently held, including expired ones not yet reclaimed.
+		Len() int
+		// Close stops the background janitor, if one is running.
+		Close() (err error)
+	}
+
+	// inMemoryCache implements the Cache interface with an in-process map,
+	// optionally bounded by evicting the least-recently-used entry.
+	inMemoryCache struct {
+		mu         sync.Mutex
+		maxEntries int                      // maximum number of entries, 0 means unbounded
+		entries    map[string]*list.Element // key to element in order
+		order      *list.List               // entries from most to least recently used
+		opts       *options
+		stop       chan struct{} // closed by Close to stop the janitor
+		stopOnce   sync.Once
+		done       chan struct{} // closed when the janitor goroutine exits
+	}
+
+	// inMemoryEntry is a single encoded value stored by inMemoryCache.
+	inMemoryEntry struct {
+		key       string
+		value     []byte
+		expiresAt time.Time // zero means no expiration
+	}
+)
+
+// SetSingle stores a single data record in memory with the specified key.
+// The value is validated and encoded with the configured codec, and stored with no expiration.
+// Returns an error if validation or encoding fails.
+func (m *inMemoryCache) SetSingle(ctx context.Context, key string, value SingleDataRecord) (err error) {
+	if err = m.opts.validate(value); err != nil {
+		return err
+	}
+	return m.set(key, value, 0)
+}
+
+// GetSingle retrieves a single data record from memory using the specified key.
+// The data is decoded with the configured codec into a SingleDataRecord.
+// Returns ErrNotFound if the key does not exist or has expired, or an error if decoding fails.
+func (m *inMemoryCache) GetSingle(ctx context.Context, key string) (result SingleDataRecord, err error) {
+	value, err := m.get(key)
+	if err != nil {
+		return nil, err
+	}
+	err = decode(m.opts.codec, key, value, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SetMultiple stores multiple data records in memory with the specified key.
+// The value is encoded with the configured codec and stored with no expiration.
+// Returns an error if encoding fails.
+func (m *inMemoryCache) SetMultiple(ctx context.Context, key string, value MultipleDataRecord) (err error) {
+	return m.set(key, value, 0)
+}
+
+// GetMultiple retrieves multiple data records from memory using the specified key.
+// The data is decoded with the configured codec into a MultipleDataRecord.
+// Returns ErrNotFound if the key does not exist or has expired, or an error if decoding fails.
+func (m *inMemoryCache) GetMultiple(ctx context.Context, key string) (result MultipleDataRecord, err error) {
+	value, err := m.get(key)
+	if err != nil {
+		return nil, err
+	}
+	err = decode(m.opts.codec, key, value, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetAndDelete atomically retrieves and removes a single data record from memory.
+// Returns ErrNotFound if the key does not exist or has expired, or an error if decoding fails.
+func (m *inMemoryCache) GetAndDelete(ctx context.Context, key string) (result SingleDataRecord, err error) {
+	m.mu.Lock()
+	elem, ok := m.entries[key]
+	if !ok {
+		m.mu.Unlock()
+		return nil, ErrNotFound
+	}
+	entry := elem.Value.(*inMemoryEntry)
+	m.removeElement(elem)
+	m.mu.Unlock()
+
+	if entry.expired(m.opts.clock.Now()) {
+		return nil, ErrNotFound
+	}
+	err = decode(m.opts.codec, key, entry.value, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ImportJSONL streams `{"key": ..., "value": ...}` records from r into memory.
+// Each record is validated and written with the given TTL (0 means no expiration).
+// Returns the number of records imported, or an error if decoding, validation or encoding fails.
+func (m *inMemoryCache) ImportJSONL(ctx context.Context, r io.Reader, ttl time.Duration) (count int, err error) {
+	return readJSONL(r, func(record *jsonlRecord) error {
+		if err := m.opts.validate(record.Value); err != nil {
+			return err
+		}
+		return m.set(record.Key, record.Value, m.opts.jitter(ttl))
+	})
+}
+
+// Delete removes the specified key from memory.
+// Deleting a key that does not exist is not an error.
+func (m *inMemoryCache) Delete(ctx context.Context, key string) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if elem, ok := m.entries[key]; ok {
+		m.removeElement(elem)
+	}
+	return nil
+}
+
+// Ping always succeeds, as the in-memory backend has no connection to check.
+func (m *inMemoryCache) Ping(ctx context.Context) (err error) {
+	return nil
+}
+
+// Len returns the number of entries currently held in memory.
+func (m *inMemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
+
+// Close stops the background janitor and waits for it to exit.
+// It is safe to call Close more than once, and on a cache without a janitor.
+func (m *inMemoryCache) Close() (err error) {
+	m.stopOnce.Do(func() {
+		close(m.stop)
+	})
+	if m.done != nil {
+		<-m.done
+	}
+	return nil
+}
+
+// runJanitor sweeps expired entries every interval until Close is called.
+func (m *inMemoryCache) runJanitor(interval time.Duration) {
+	defer close(m.done)
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-m.opts.clock.After(interval):
+			m.deleteExpired()
+		}
+	}
+}
+
+// deleteExpired removes every entry whose expiration time has passed.
+func (m *inMemoryCache) deleteExpired() {
+	now := m.opts.clock.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for elem := m.order.Back(); elem != nil; {
+		prev := elem.Prev()
+		if elem.Value.(*inMemoryEntry).expired(now) {
+			m.removeElement(elem)
+		}
+		elem = prev
+	}
+}
+
+// set encodes and stores the value under key.
+func (m *inMemoryCache) set(key string, value interface{}, ttl time.Duration) (err error) {
+	data, err := m.opts.codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.store(key, data, ttl)
+	return nil
+}
+
+// store saves the encoded value under key, marking it most recently used
+// and evicting the least recently used entry when the capacity is exceeded.
+// The caller must hold m.mu.
+func (m *inMemoryCache) store(key string, data []byte, ttl time.Duration) {
+	entry := &inMemoryEntry{key: key, value: data}
+	if ttl > 0 {
+		entry.expiresAt = m.opts.clock.Now().Add(ttl)
+	}
+
+	if elem, ok := m.entries[key]; ok {
+		elem.Value = entry
+		m.order.MoveToFront(elem)
+		return
+	}
+	m.entries[key] = m.order.PushFront(entry)
+	if m.maxEntries > 0 && m.order.Len() > m.maxEntries {
+		m.removeElement(m.order.Back())
+	}
+}
+
+// get returns the encoded value stored under key and marks it most recently used.
+// Expired entries are removed and reported as ErrNotFound.
+func (m *inMemoryCache) get(key string) (value []byte, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.getLocked(key)
+}
+
+// getLocked is get for callers that already hold m.mu.
+func (m *inMemoryCache) getLocked(key string) (value []byte, err error) {
+	elem, ok := m.entries[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	entry := elem.Value.(*inMemoryEntry)
+	if entry.expired(m.opts.clock.Now()) {
+		m.removeElement(elem)
+		return nil, ErrNotFound
+	}
+	m.order.MoveToFront(elem)
+	return entry.value, nil
+}
+
+// removeElement deletes the element from both the index and the recency list.
+// The caller must hold m.mu.
+func (m *inMemoryCache) removeElement(elem *list.Element) {
+	m.order.Remove(elem)
+	delete(m.entries, elem.Value.(*inMemoryEntry).key)
+}
+
+// expired reports whether the entry's expiration time has passed at now.
+func (e *inMemoryEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// NewInMemory creates a new unbounded in-process cache.
+// It is mainly useful as a test double for the network backends.
+// Returns an InMemoryCache interface implementation.
+func NewInMemory(opts ...Option) InMemoryCache {
+	return NewInMemoryLRU(0, opts...)
+}
+
+// NewInMemoryLRU creates a new in-process cache holding at most maxEntries entries.
+// When the capacity is exceeded the least recently used entry is evicted; TTLs are still honored.
+// A maxEntries of 0 or less means the cache is unbounded.
+// When WithJanitorInterval is given, expired entries are also swept in the background until Close is called.
+// Returns an InMemoryCache interface implementation.
+func NewInMemoryLRU(maxEntries int, opts ...Option) InMemoryCache {
+	if maxEntries < 0 {
+		maxEntries = 0
+	}
+	m := &inMemoryCache{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		opts:       newOptions(opts...),
+		stop:       make(chan struct{}),
+	}
+	if m.opts.janitorInterval > 0 {
+		m.done = make(chan struct{})
+		go m.runJanitor(m.opts.janitorInterval)
+	}
+	return m
+}
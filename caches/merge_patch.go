@@ -0,0 +1,211 @@
+package caches
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/redis/go-redis/v9"
+)
+
+// maxMergePatchRetries bounds how often a merge patch is retried after a concurrent update.
+const maxMergePatchRetries = 10
+
+// errMergePatchConflict is returned when a merge patch kept losing races with concurrent writers.
+var errMergePatchConflict = errors.New("cache: merge patch aborted after repeated concurrent updates")
+
+// applyMergePatch applies an RFC 7386 JSON merge patch to target and returns the result.
+// Object members set to null in the patch are removed, nested objects are merged recursively,
+// and any other patch value replaces the target outright.
+func applyMergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{})
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = applyMergePatch(targetObject[name], value)
+	}
+	return targetObject
+}
+
+// mergePatchEncoded decodes the current value with the codec, applies the patch and re-encodes it.
+// A nil current value is treated as a missing document. JSON numbers are decoded exactly,
+// so integers beyond 2^53 the patch does not touch keep their value.
+func mergePatchEncoded(codec Codec, current []byte, patch interface{}) (data []byte, err error) {
+	var document interface{}
+	if current != nil {
+		if err = preserveNumbers(codec).Unmarshal(current, &document); err != nil {
+			return nil, err
+		}
+	}
+	return codec.Marshal(resolveNumbers(applyMergePatch(document, patch)))
+}
+
+// decodeMergePatch parses the raw patch document, keeping its numbers as json.Number.
+func decodeMergePatch(patch json.RawMessage) (decoded interface{}, err error) {
+	if err = (JSONCodec{UseNumber: true}).Unmarshal(patch, &decoded); err != nil {
+		return nil, fmt.Errorf("cache: invalid merge patch: %w", err)
+	}
+	return decoded, nil
+}
+
+// preserveNumbers returns the codec set up to decode JSON numbers as json.Number.
+// Other codecs are returned unchanged.
+func preserveNumbers(codec Codec) Codec {
+	switch c := codec.(type) {
+	case JSONCodec:
+		c.UseNumber = true
+		return c
+	case MarkedCodec:
+		if c.Write == nil {
+			c.Write = JSONCodec{}
+		}
+		if c.Legacy == nil {
+			c.Legacy = JSONCodec{}
+		}
+		c.Write, c.Legacy = preserveNumbers(c.Write), preserveNumbers(c.Legacy)
+		return c
+	}
+	return codec
+}
+
+// resolveNumbers replaces the json.Number values in a decoded document with int64, uint64 or float64,
+// so every codec encodes them as numbers.
+func resolveNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		if n, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+			return n
+		}
+		n, _ := v.Float64()
+		return n
+	case map[string]interface{}:
+		for name, element := range v {
+			v[name] = resolveNumbers(element)
+		}
+	case []interface{}:
+		for i, element := range v {
+			v[i] = resolveNumbers(element)
+		}
+	}
+	return value
+}
+
+// MergePatch applies an RFC 7386 JSON merge patch to the document stored in Redis and writes it back
+// with the given TTL (0 means no expiration). A missing key is patched as an empty document.
+// The read-modify-write runs in a WATCH transaction and is retried if the key changes concurrently,
+// so the update is atomic. A Lua script is not used because Redis' cjson loses integer precision
+// and cannot tell empty arrays from empty objects.
+// Returns an error if the patch is invalid, decoding or encoding fails, or the transaction keeps conflicting.
+func (r *redisCache) MergePatch(ctx context.Context, key string, patch json.RawMessage, ttl time.Duration) (err error) {
+	decoded, err := decodeMergePatch(patch)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; attempt < maxMergePatchRetries; attempt++ {
+		err = r.client.Watch(ctx, func(tx *redis.Tx) error {
+			current, err := tx.Get(ctx, key).Bytes()
+			if err != nil && !errors.Is(err, redis.Nil) {
+				return err
+			}
+			data, err := mergePatchEncoded(r.opts.codec, current, decoded)
+			if err != nil {
+				return err
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.Set(ctx, key, data, ttl)
+				return nil
+			})
+			return err
+		}, key)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+	}
+	return errMergePatchConflict
+}
+
+// MergePatch applies an RFC 7386 JSON merge patch to the document stored in Memcache and writes it back
+// with the given TTL (0 means no expiration). A missing key is patched as an empty document.
+// The update uses CAS tokens and is retried if the key changes concurrently.
+// Returns an error if the patch is invalid, decoding or encoding fails, or the update keeps conflicting.
+func (m *memcacheCache) MergePatch(ctx context.Context, key string, patch json.RawMessage, ttl time.Duration) (err error) {
+	decoded, err := decodeMergePatch(patch)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; attempt < maxMergePatchRetries; attempt++ {
+		item, err := m.client.Get(key)
+		if err != nil && !errors.Is(err, memcache.ErrCacheMiss) {
+			return err
+		}
+
+		var current []byte
+		if item != nil {
+			current = item.Value
+		}
+		data, err := mergePatchEncoded(m.opts.codec, current, decoded)
+		if err != nil {
+			return err
+		}
+
+		if item == nil {
+			err = m.client.Add(&memcache.Item{Key: key, Value: data, Expiration: memcacheExpiration(ttl)})
+		} else {
+			item.Value = data
+			item.Expiration = memcacheExpiration(ttl)
+			err = m.client.CompareAndSwap(item)
+		}
+		switch {
+		case err == nil:
+			return nil
+		case errors.Is(err, memcache.ErrNotStored), errors.Is(err, memcache.ErrCASConflict):
+			continue
+		default:
+			return err
+		}
+	}
+	return errMergePatchConflict
+}
+
+// MergePatch applies an RFC 7386 JSON merge patch to the document stored in memory and writes it back
+// with the given TTL (0 means no expiration). A missing key is patched as an empty document.
+// Returns an error if the patch is invalid, or decoding or encoding fails.
+func (m *inMemoryCache) MergePatch(ctx context.Context, key string, patch json.RawMessage, ttl time.Duration) (err error) {
+	decoded, err := decodeMergePatch(patch)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var current []byte
+	if elem, ok := m.entries[key]; ok {
+		if entry := elem.Value.(*inMemoryEntry); !entry.expired(m.opts.clock.Now()) {
+			current = entry.value
+		}
+	}
+	data, err := mergePatchEncoded(m.opts.codec, current, decoded)
+	if err != nil {
+		return err
+	}
+	m.store(key, data, ttl)
+	return nil
+}
@@ -0,0 +1,178 @@
+package caches
+
+import (
+	"crypto/tls"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+type (
+	// Validator checks that a value matches the expected shape before it is cached.
+	// A non-nil error rejects the value and the write is skipped.
+	Validator func(value SingleDataRecord) (err error)
+
+	// Option configures optional behaviour of a Cache backend.
+	Option func(*options)
+
+	// options holds the optional settings shared by the Cache backends.
+	options struct {
+		validator       Validator
+		codec           Codec
+		janitorInterval time.Duration
+
+		reconnectRetries int
+		reconnectBackoff time.Duration
+
+		username  string
+		password  string
+		database  int
+		tlsConfig *tls.Config
+
+		clock Clock
+
+		listStorage bool
+
+		ttlJitter float64    // maximum TTL deviation as a fraction of the TTL, 0 disables jitter
+		randMu    sync.Mutex // guards rand
+		rand      *rand.Rand // source of jitter, nil uses the global generator
+	}
+)
+
+// WithValidator registers a Validator that SetSingle runs before storing a value.
+// When the validator returns an error the value never reaches the backend.
+func WithValidator(validator Validator) Option {
+	return func(o *options) {
+		o.validator = validator
+	}
+}
+
+// WithCodec sets the Codec used to encode and decode cached values.
+// Backends use JSONCodec when no codec is given.
+func WithCodec(codec Codec) Option {
+	return func(o *options) {
+		o.codec = codec
+	}
+}
+
+// WithJanitorInterval starts a background janitor on the in-memory backend that sweeps
+// expired entries every interval, so keys that are never read again do not leak memory.
+// The janitor is stopped by the cache's Close method. Other backends ignore this option.
+func WithJanitorInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.janitorInterval = interval
+	}
+}
+
+// WithReconnect makes the Redis backend retry operations that fail because the connection
+// was refused or dropped, e.g. while Redis restarts. Each retry re-dials the server after waiting
+// backoff times the attempt number, up to retries times, so a brief outage does not fail a wave of requests.
+// Any command is retried when the connection could not be established, as it never reached the server.
+// A command that failed on a dropped connection may already have run, so only read-only commands and
+// pipelines of them are retried then; writes, scripts and transactions fail instead of being applied twice.
+// Other backends ignore this option.
+func WithReconnect(retries int, backoff time.Duration) Option {
+	return func(o *options) {
+		o.reconnectRetries = retries
+		o.reconnectBackoff = backoff
+	}
+}
+
+// WithCredentials authenticates to Redis with the username and password.
+// With Redis 6+ ACLs this connects as a named user, whose permissions bound what the cache may run;
+// commands the user is not allowed to run fail with Redis' NOPERM error.
+// An empty username authenticates with the password only, as the "default" user.
+// Other backends ignore this option.
+func WithCredentials(username, password string) Option {
+	return func(o *options) {
+		o.username = username
+		o.password = password
+	}
+}
+
+// WithDatabase selects the numbered Redis database the cache reads and writes, 0 by default.
+// Other backends ignore this option.
+func WithDatabase(db int) Option {
+	return func(o *options) {
+		o.database = db
+	}
+}
+
+// WithTLS connects to Redis over TLS with the given configuration, e.g. for managed Redis services.
+// Other backends ignore this option.
+func WithTLS(config *tls.Config) Option {
+	return func(o *options) {
+		o.tlsConfig = config
+	}
+}
+
+// WithClock sets the Clock the in-memory backend uses to expire entries and schedule its janitor,
+// so tests can expire keys by advancing a fake clock. Backends default to the real clock;
+// the network backends leave expiry to the server and ignore this option.
+func WithClock(clock Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// WithListStorage makes the Redis backend store MultipleDataRecord values as native Redis lists,
+// one encoded element per item, instead of a single encoded array. Appending with AppendMultiple then
+// only sends the new elements, and the list can be trimmed or ranged over with Redis list commands.
+// This changes the storage format, so values written without the option cannot be read with it and vice versa.
+// Since Redis does not keep empty lists, storing an empty slice deletes the key and reads report ErrNotFound.
+// Other backends ignore this option.
+func WithListStorage() Option {
+	return func(o *options) {
+		o.listStorage = true
+	}
+}
+
+// WithTTLJitter randomly lengthens or shortens every TTL given to a set operation by up to percent
+// of its value, so keys warmed together with the same TTL do not all expire at once.
+// The percentage is clamped to [0, 99] so a jittered TTL never reaches zero, which would mean no expiration.
+// A nil source uses the global random generator; pass a seeded source for reproducible TTLs in tests.
+// SetNX, CompareAndSwap and MergePatch keep their exact TTL, since those are used for locks and claims.
+func WithTTLJitter(percent float64, source rand.Source) Option {
+	return func(o *options) {
+		o.ttlJitter = min(max(percent, 0), 99) / 100
+		if source != nil {
+			o.rand = rand.New(source)
+		}
+	}
+}
+
+// jitter applies the configured TTL jitter to ttl. TTLs of zero or less mean no expiration and are kept as-is.
+func (o *options) jitter(ttl time.Duration) time.Duration {
+	if o.ttlJitter == 0 || ttl <= 0 {
+		return ttl
+	}
+	var f float64
+	if o.rand != nil {
+		o.randMu.Lock()
+		f = o.rand.Float64()
+		o.randMu.Unlock()
+	} else {
+		f = rand.Float64()
+	}
+	return time.Duration(float64(ttl) * (1 + o.ttlJitter*(2*f-1)))
+}
+
+// newOptions applies the given Option values on top of the defaults.
+func newOptions(opts ...Option) *options {
+	o := &options{
+		codec: JSONCodec{},
+		clock: realClock{},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// validate runs the configured Validator, if any, against the value.
+func (o *options) validate(value SingleDataRecord) (err error) {
+	if o.validator == nil {
+		return nil
+	}
+	return o.validator(value)
+}
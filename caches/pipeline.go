@@ -0,0 +1,208 @@
+package caches
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	_ Pipeline = &redisPipeline{}
+	_ Pipeline = &sequentialPipeline{}
+)
+
+// Pipeline operation kinds.
+const (
+	pipelineSet = iota
+	pipelineGet
+	pipelineDelete
+)
+
+type (
+	// Pipeline buffers cache commands and executes them together with Exec.
+	Pipeline interface {
+		// Set queues storing a single data record with the given TTL (0 means no expiration).
+		Set(key string, value SingleDataRecord, ttl time.Duration) Pipeline
+		// Get queues retrieving a single data record.
+		Get(key string) Pipeline
+		// Delete queues removing a key.
+		Delete(key string) Pipeline
+		// Exec runs the queued commands and returns one Result per command, in queue order.
+		Exec(ctx context.Context) (results []Result, err error)
+	}
+
+	// Result holds the outcome of a single pipelined command.
+	Result struct {
+		Key   string           // The key the command operated on
+		Value SingleDataRecord // The retrieved value, set only for Get
+		Err   error            // The command error, ErrNotFound for a Get miss
+	}
+
+	// pipelineOp is a single queued pipeline command.
+	pipelineOp struct {
+		kind  int
+		key   string
+		value SingleDataRecord
+		ttl   time.Duration
+	}
+
+	// redisPipeline executes queued commands in a single Redis round trip.
+	redisPipeline struct {
+		cache *redisCache
+		ops   []pipelineOp
+	}
+
+	// sequentialPipeline executes queued commands one by one for backends without pipelining.
+	sequentialPipeline struct {
+		set func(ctx context.Context, key string, value SingleDataRecord, ttl time.Duration) error
+		get func(ctx context.Context, key string) (SingleDataRecord, error)
+		del func(ctx context.Context, key string) error
+		ops []pipelineOp
+	}
+)
+
+// Set queues storing a single data record in Redis.
+func (p *redisPipeline) Set(key string, value SingleDataRecord, ttl time.Duration) Pipeline {
+	p.ops = append(p.ops, pipelineOp{kind: pipelineSet, key: key, value: value, ttl: ttl})
+	return p
+}
+
+// Get queues retrieving a single data record from Redis.
+func (p *redisPipeline) Get(key string) Pipeline {
+	p.ops = append(p.ops, pipelineOp{kind: pipelineGet, key: key})
+	return p
+}
+
+// Delete queues removing a key from Redis.
+func (p *redisPipeline) Delete(key string) Pipeline {
+	p.ops = append(p.ops, pipelineOp{kind: pipelineDelete, key: key})
+	return p
+}
+
+// Exec sends all queued commands to Redis in a single round trip using redis.Pipelined.
+// Values are validated and encoded with the configured codec before sending; a value that fails is reported
+// in its Result and not sent. Retrieved values are decoded with the configured codec.
+// Returns the per-command results, and the first error other than a Get miss.
+func (p *redisPipeline) Exec(ctx context.Context) (results []Result, err error) {
+	results = make([]Result, len(p.ops))
+	cmds := make([]redis.Cmder, len(p.ops))
+	_, _ = p.cache.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, op := range p.ops {
+			results[i].Key = op.key
+			switch op.kind {
+			case pipelineSet:
+				if results[i].Err = p.cache.opts.validate(op.value); results[i].Err != nil {
+					continue
+				}
+				value, err := p.cache.opts.codec.Marshal(op.value)
+				if err != nil {
+					results[i].Err = err
+					continue
+				}
+				cmds[i] = pipe.Set(ctx, op.key, value, p.cache.opts.jitter(op.ttl))
+			case pipelineGet:
+				cmds[i] = pipe.Get(ctx, op.key)
+			case pipelineDelete:
+				cmds[i] = pipe.Del(ctx, op.key)
+			}
+		}
+		return nil
+	})
+	p.ops = nil
+
+	for i, cmd := range cmds {
+		if cmd == nil {
+			continue
+		}
+		if cmd.Err() != nil {
+			results[i].Err = cmd.Err()
+			if errors.Is(cmd.Err(), redis.Nil) {
+				results[i].Err = ErrNotFound
+			}
+			continue
+		}
+		if get, ok := cmd.(*redis.StringCmd); ok {
+			results[i].Err = decode(p.cache.opts.codec, results[i].Key, []byte(get.Val()), &results[i].Value)
+		}
+	}
+	return results, firstPipelineError(results)
+}
+
+// Set queues storing a single data record.
+func (p *sequentialPipeline) Set(key string, value SingleDataRecord, ttl time.Duration) Pipeline {
+	p.ops = append(p.ops, pipelineOp{kind: pipelineSet, key: key, value: value, ttl: ttl})
+	return p
+}
+
+// Get queues retrieving a single data record.
+func (p *sequentialPipeline) Get(key string) Pipeline {
+	p.ops = append(p.ops, pipelineOp{kind: pipelineGet, key: key})
+	return p
+}
+
+// Delete queues removing a key.
+func (p *sequentialPipeline) Delete(key string) Pipeline {
+	p.ops = append(p.ops, pipelineOp{kind: pipelineDelete, key: key})
+	return p
+}
+
+// Exec runs the queued commands one after another against the backend.
+// Returns the per-command results, and the first error other than a Get miss.
+func (p *sequentialPipeline) Exec(ctx context.Context) (results []Result, err error) {
+	results = make([]Result, len(p.ops))
+	for i, op := range p.ops {
+		results[i].Key = op.key
+		switch op.kind {
+		case pipelineSet:
+			results[i].Err = p.set(ctx, op.key, op.value, op.ttl)
+		case pipelineGet:
+			results[i].Value, results[i].Err = p.get(ctx, op.key)
+		case pipelineDelete:
+			results[i].Err = p.del(ctx, op.key)
+		}
+	}
+	p.ops = nil
+	return results, firstPipelineError(results)
+}
+
+// firstPipelineError returns the first command error that is not a Get miss.
+func firstPipelineError(results []Result) error {
+	for _, result := range results {
+		if result.Err != nil && !errors.Is(result.Err, ErrNotFound) {
+			return result.Err
+		}
+	}
+	return nil
+}
+
+// Pipeline returns a builder whose commands are sent to Redis in a single round trip.
+func (r *redisCache) Pipeline() (pipeline Pipeline) {
+	return &redisPipeline{cache: r}
+}
+
+// Pipeline returns a builder whose commands are executed sequentially against Memcache.
+func (m *memcacheCache) Pipeline() (pipeline Pipeline) {
+	return &sequentialPipeline{
+		set: m.SetSingleWithTTL,
+		get: func(ctx context.Context, key string) (SingleDataRecord, error) {
+			result, err := m.GetSingle(ctx, key)
+			if errors.Is(err, memcache.ErrCacheMiss) {
+				return nil, ErrNotFound
+			}
+			return result, err
+		},
+		del: m.Delete,
+	}
+}
+
+// Pipeline returns a builder whose commands are executed sequentially against memory.
+func (m *inMemoryCache) Pipeline() (pipeline Pipeline) {
+	return &sequentialPipeline{
+		set: m.SetSingleWithTTL,
+		get: m.GetSingle,
+		del: m.Delete,
+	}
+}
@@ -0,0 +1,26 @@
+package caches
+
+import "context"
+
+// Publish sends the message to every subscriber of the Redis pub/sub channel.
+// Returns an error if the publish fails.
+func (r *redisCache) Publish(ctx context.Context, channel, message string) (err error) {
+	return r.client.Publish(ctx, channel, message).Err()
+}
+
+// Subscribe calls fn with every message published to the Redis pub/sub channel until unsubscribe is called.
+// Messages are delivered one at a time on a background goroutine.
+// Returns an error if the subscription could not be confirmed.
+func (r *redisCache) Subscribe(ctx context.Context, channel string, fn func(message string)) (unsubscribe func() error, err error) {
+	pubsub := r.client.Subscribe(ctx, channel)
+	if _, err = pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return nil, err
+	}
+	go func() {
+		for msg := range pubsub.Channel() {
+			fn(msg.Payload)
+		}
+	}()
+	return pubsub.Close, nil
+}
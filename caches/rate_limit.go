@@ -0,0 +1,40 @@
+package caches
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// allowScript counts a request in the fixed window stored at KEYS[1].
+// ARGV[1] is the limit and ARGV[2] the window length in milliseconds; the window starts with the first request.
+const allowScript = `
+local current = redis.call('INCR', KEYS[1])
+if current == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+if current > tonumber(ARGV[1]) then
+	return 0
+end
+return 1
+`
+
+// AllowN reports whether a request identified by key is allowed under a fixed-window rate limit
+// of limit requests per window, shared by every instance using the same Redis.
+// The window starts with the first request and the counter resets once it expires.
+// Denied requests still count towards the current window.
+// Returns an error if limit or window is not positive, or if the script execution fails.
+func (r *redisCache) AllowN(ctx context.Context, key string, limit int, window time.Duration) (allowed bool, err error) {
+	if limit <= 0 {
+		return false, fmt.Errorf("cache: rate limit must be positive, got %d", limit)
+	}
+	if window < time.Millisecond {
+		return false, fmt.Errorf("cache: rate limit window must be at least 1ms, got %s", window)
+	}
+
+	result, err := r.runScript(ctx, allowScript, []string{key}, limit, window.Milliseconds())
+	if err != nil {
+		return false, err
+	}
+	return result == int64(1), nil
+}
@@ -0,0 +1,114 @@
+package caches
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var _ redis.Hook = &reconnectHook{}
+
+// readOnlyCommands lists the commands, by lowercase name, that are safe to retry after the connection
+// dropped mid-command, because running them twice has no further effect.
+var readOnlyCommands = map[string]bool{
+	"bitcount": true, "dbsize": true, "exists": true, "geodist": true, "geopos": true,
+	"georadius_ro": true, "georadiusbymember_ro": true, "geosearch": true, "get": true, "getbit": true,
+	"hexists": true, "hget": true, "hgetall": true, "hlen": true, "hmget": true, "info": true,
+	"lindex": true, "llen": true, "lrange": true, "mget": true, "ping": true, "pttl": true,
+	"scan": true, "scard": true, "sismember": true, "smembers": true, "strlen": true, "ttl": true,
+	"type": true, "xlen": true, "xrange": true, "xrevrange": true, "zcard": true, "zcount": true,
+	"zrange": true, "zrangebyscore": true, "zrank": true, "zrevrange": true, "zrevrank": true, "zscore": true,
+}
+
+// reconnectHook retries Redis commands that failed because the connection was lost.
+// go-redis drops a connection from its pool after a network error, so each retry dials a fresh one.
+// Commands that may have run before the connection dropped are only retried when they are read-only.
+type reconnectHook struct {
+	retries int
+	backoff time.Duration
+}
+
+// isConnectionError reports whether err means the connection to Redis was refused or dropped.
+func isConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	return isDialError(err) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) || errors.Is(err, net.ErrClosed)
+}
+
+// isDialError reports whether err means the connection to Redis could not be established,
+// so the command was never sent.
+func isDialError(err error) bool {
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// readOnly reports whether every command is in readOnlyCommands.
+func readOnly(cmds ...redis.Cmder) bool {
+	for _, cmd := range cmds {
+		if !readOnlyCommands[cmd.Name()] {
+			return false
+		}
+	}
+	return true
+}
+
+// retry runs fn until it succeeds with something other than a retryable connection error,
+// waiting a linearly growing backoff between attempts and giving up once the retries
+// are exhausted or the context is done. Dial errors are always retryable; other connection errors
+// only when idempotent is set, as the commands may already have run.
+func (h *reconnectHook) retry(ctx context.Context, idempotent bool, fn func() error) (err error) {
+	retryable := func(err error) bool {
+		return isDialError(err) || (idempotent && isConnectionError(err))
+	}
+	err = fn()
+	for attempt := 1; attempt <= h.retries && retryable(err); attempt++ {
+		timer := time.NewTimer(h.backoff * time.Duration(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		err = fn()
+	}
+	return err
+}
+
+// DialHook leaves dialing unchanged.
+func (h *reconnectHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+// ProcessHook retries a single command after a connection error, if it cannot have run yet or is read-only.
+func (h *reconnectHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		return h.retry(ctx, readOnly(cmd), func() error {
+			cmd.SetErr(nil)
+			return next(ctx, cmd)
+		})
+	}
+}
+
+// ProcessPipelineHook retries a whole pipeline after a connection error,
+// if it cannot have run yet or consists of read-only commands only. Transactions include MULTI and EXEC,
+// so they are only retried when the connection could not be established.
+func (h *reconnectHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		return h.retry(ctx, readOnly(cmds...), func() error {
+			for _, cmd := range cmds {
+				cmd.SetErr(nil)
+			}
+			return next(ctx, cmds)
+		})
+	}
+}
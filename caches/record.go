@@ -0,0 +1,211 @@
+package caches
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Recorded operation names.
+const (
+	opSetSingle    = "set_single"
+	opGetSingle    = "get_single"
+	opSetMultiple  = "set_multiple"
+	opGetMultiple  = "get_multiple"
+	opGetAndDelete = "get_and_delete"
+	opDelete       = "delete"
+)
+
+// notFoundError is how a miss, ErrNotFound or a backend's raw miss error, is written to a recording.
+const notFoundError = "not_found"
+
+var (
+	_ Cache = &recordingCache{}
+	_ Cache = &replayCache{}
+)
+
+type (
+	// interaction is a single recorded cache operation, written as one JSONL line.
+	interaction struct {
+		Op    string      `json:"op"`
+		Key   string      `json:"key"`
+		Value interface{} `json:"value,omitempty"`
+		Bytes bool        `json:"bytes,omitempty"` // Value is raw bytes, such as Memcache returns, written as base64
+		Error string      `json:"error,omitempty"`
+	}
+
+	// recordingCache passes operations through to a Cache and logs each one to a writer.
+	recordingCache struct {
+		Cache
+		mu      sync.Mutex
+		encoder *json.Encoder
+	}
+
+	// replayCache serves reads from a recording instead of a real backend.
+	replayCache struct {
+		Cache
+		mu      sync.Mutex
+		results map[string][]*interaction // recorded reads per op and key, in recorded order
+	}
+)
+
+// record writes the interaction, keeping concurrent operations on separate lines.
+// Recording failures are ignored so they never affect the cache operation itself.
+func (r *recordingCache) record(op, key string, value interface{}, err error) {
+	entry := &interaction{Op: op, Key: key, Value: value}
+	_, entry.Bytes = value.([]byte)
+	if isNotFound(err) {
+		entry.Error = notFoundError
+	} else if err != nil {
+		entry.Error = err.Error()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_ = r.encoder.Encode(entry)
+}
+
+// SetSingle stores the value in the underlying cache and records the call.
+func (r *recordingCache) SetSingle(ctx context.Context, key string, value SingleDataRecord) (err error) {
+	err = r.Cache.SetSingle(ctx, key, value)
+	r.record(opSetSingle, key, value, err)
+	return err
+}
+
+// GetSingle reads the value from the underlying cache and records the result.
+func (r *recordingCache) GetSingle(ctx context.Context, key string) (result SingleDataRecord, err error) {
+	result, err = r.Cache.GetSingle(ctx, key)
+	r.record(opGetSingle, key, result, err)
+	return result, err
+}
+
+// SetMultiple stores the values in the underlying cache and records the call.
+func (r *recordingCache) SetMultiple(ctx context.Context, key string, value MultipleDataRecord) (err error) {
+	err = r.Cache.SetMultiple(ctx, key, value)
+	r.record(opSetMultiple, key, value, err)
+	return err
+}
+
+// GetMultiple reads the values from the underlying cache and records the result.
+func (r *recordingCache) GetMultiple(ctx context.Context, key string) (result MultipleDataRecord, err error) {
+	result, err = r.Cache.GetMultiple(ctx, key)
+	r.record(opGetMultiple, key, result, err)
+	return result, err
+}
+
+// GetAndDelete reads and removes the value in the underlying cache and records the result.
+func (r *recordingCache) GetAndDelete(ctx context.Context, key string) (result SingleDataRecord, err error) {
+	result, err = r.Cache.GetAndDelete(ctx, key)
+	r.record(opGetAndDelete, key, result, err)
+	return result, err
+}
+
+// Delete removes the key from the underlying cache and records the call.
+func (r *recordingCache) Delete(ctx context.Context, key string) (err error) {
+	err = r.Cache.Delete(ctx, key)
+	r.record(opDelete, key, nil, err)
+	return err
+}
+
+// next pops the next recorded result of op for key.
+// Returns an error if the recording holds no further result for it.
+func (r *replayCache) next(op, key string) (value interface{}, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id := op + "\x00" + key
+	queue := r.results[id]
+	if len(queue) == 0 {
+		return nil, fmt.Errorf("cache: no recorded %s for key %q", op, key)
+	}
+	entry := queue[0]
+	r.results[id] = queue[1:]
+
+	switch entry.Error {
+	case "":
+		if encoded, ok := entry.Value.(string); ok && entry.Bytes {
+			return base64.StdEncoding.DecodeString(encoded)
+		}
+		return entry.Value, nil
+	case notFoundError:
+		return nil, ErrNotFound
+	default:
+		return nil, errors.New(entry.Error)
+	}
+}
+
+// SetSingle accepts the write without storing it; reads are served from the recording.
+func (r *replayCache) SetSingle(ctx context.Context, key string, value SingleDataRecord) (err error) {
+	return nil
+}
+
+// GetSingle returns the next recorded GetSingle result for the key.
+func (r *replayCache) GetSingle(ctx context.Context, key string) (result SingleDataRecord, err error) {
+	return r.next(opGetSingle, key)
+}
+
+// SetMultiple accepts the write without storing it; reads are served from the recording.
+func (r *replayCache) SetMultiple(ctx context.Context, key string, value MultipleDataRecord) (err error) {
+	return nil
+}
+
+// GetMultiple returns the next recorded GetMultiple result for the key.
+func (r *replayCache) GetMultiple(ctx context.Context, key string) (result MultipleDataRecord, err error) {
+	value, err := r.next(opGetMultiple, key)
+	if err != nil {
+		return nil, err
+	}
+	values, _ := value.([]interface{})
+	return values, nil
+}
+
+// GetAndDelete returns the next recorded GetAndDelete result for the key.
+func (r *replayCache) GetAndDelete(ctx context.Context, key string) (result SingleDataRecord, err error) {
+	return r.next(opGetAndDelete, key)
+}
+
+// Delete accepts the deletion without applying it; reads are served from the recording.
+func (r *replayCache) Delete(ctx context.Context, key string) (err error) {
+	return nil
+}
+
+// NewRecorder wraps a Cache so every SetSingle, GetSingle, SetMultiple, GetMultiple, GetAndDelete
+// and Delete call is logged to w as a JSONL record of the operation, key, value and error.
+// The recording can be replayed with NewReplayer for golden-file testing of cache behaviour.
+// Returns the recording Cache implementation.
+func NewRecorder(cache Cache, w io.Writer) Cache {
+	return &recordingCache{
+		Cache:   cache,
+		encoder: json.NewEncoder(w),
+	}
+}
+
+// NewReplayer creates a Cache that serves reads from a recording made by NewRecorder.
+// Each read returns the next recorded result for the same operation and key, in recorded order,
+// so replaying the same sequence of calls yields identical results. Misses are replayed as ErrNotFound
+// and raw byte values as []byte. Writes are accepted and ignored.
+// Operations that are not recorded are served by an empty in-memory cache.
+// Returns an error if the recording cannot be decoded.
+func NewReplayer(r io.Reader) (cache Cache, err error) {
+	replay := &replayCache{
+		Cache:   NewInMemory(),
+		results: make(map[string][]*interaction),
+	}
+	decoder := json.NewDecoder(r)
+	for {
+		entry := &interaction{}
+		if err = decoder.Decode(entry); err != nil {
+			if errors.Is(err, io.EOF) {
+				return replay, nil
+			}
+			return nil, err
+		}
+		switch entry.Op {
+		case opGetSingle, opGetMultiple, opGetAndDelete:
+			id := entry.Op + "\x00" + entry.Key
+			replay.results[id] = append(replay.results[id], entry)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package caches
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// renewIfOwnerScript resets the expiry of KEYS[1] to ARGV[2] milliseconds only when it holds ARGV[1].
+const renewIfOwnerScript = `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`
+
+// RenewIfOwner atomically resets the key's expiration to ttl only if it still holds token,
+// for renewing a lease or lock claimed with SetNX without extending one that has meanwhile expired and been claimed by someone else.
+// The token is encoded with the configured codec and compared with the stored bytes, so it matches a token
+// stored through SetNX or SetSingleWithTTL. The TTL is applied with millisecond precision.
+// Returns whether the key was renewed, false if it is missing or holds another value,
+// or an error if ttl is below 1ms, which would delete the key instead, or if the script execution fails.
+func (r *redisCache) RenewIfOwner(ctx context.Context, key, token string, ttl time.Duration) (renewed bool, err error) {
+	if ttl < time.Millisecond {
+		return false, fmt.Errorf("cache: renewal TTL must be at least 1ms, got %s", ttl)
+	}
+	encoded, err := r.opts.codec.Marshal(token)
+	if err != nil {
+		return false, err
+	}
+	result, err := r.runScript(ctx, renewIfOwnerScript, []string{key}, encoded, ttl.Milliseconds())
+	if err != nil {
+		return false, err
+	}
+	return result == int64(1), nil
+}
@@ -0,0 +1,61 @@
+package caches
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// NewRedisWithReplicas creates a Redis cache that sends writes to the primary and spreads reads
+// across the replicas in round-robin order. Addresses are given as host:port.
+// A read that fails on a replica for any reason other than a missing key is retried on the primary,
+// so a lagging or unreachable replica degrades to primary reads instead of failing.
+// Replicas replicate asynchronously, so a read right after a write may not see it yet.
+// Optional behaviour such as value validation is configured through opts.
+// Returns a RedisCache interface implementation using Redis as the backend.
+func NewRedisWithReplicas(primary string, replicas []string, opts ...Option) RedisCache {
+	o := newOptions(opts...)
+	cache := &redisCache{
+		client: newRedisClient(primary, o),
+		opts:   o,
+	}
+	for _, addr := range replicas {
+		cache.replicas = append(cache.replicas, newRedisClient(addr, o))
+	}
+	return cache
+}
+
+// newRedisClient creates a Redis client for the address with the hooks the options ask for.
+func newRedisClient(addr string, o *options) *redis.Client {
+	client := redis.NewClient(&redis.Options{
+		Addr:      addr,
+		Username:  o.username,
+		Password:  o.password,
+		DB:        o.database,
+		TLSConfig: o.tlsConfig,
+	})
+	if o.reconnectRetries > 0 {
+		client.AddHook(&reconnectHook{retries: o.reconnectRetries, backoff: o.reconnectBackoff})
+	}
+	return client
+}
+
+// get reads the key from the next replica, falling back to the primary if the replica errors.
+// Without replicas it reads from the primary.
+func (r *redisCache) get(ctx context.Context, key string) (value string, err error) {
+	if len(r.replicas) == 0 {
+		return r.client.Get(ctx, key).Result()
+	}
+	replica := r.replicas[int(r.nextReplica.Add(1)-1)%len(r.replicas)]
+	value, err = replica.Get(ctx, key).Result()
+	if err == nil || errors.Is(err, redis.Nil) {
+		return value, err
+	}
+	value, err = r.client.Get(ctx, key).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return "", fmt.Errorf("cache: read from primary after replica failure: %w", err)
+	}
+	return value, err
+}
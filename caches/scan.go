@@ -0,0 +1,92 @@
+package caches
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+)
+
+// scanBatchSize is the COUNT hint passed to each Redis SCAN call.
+const scanBatchSize = 100
+
+// Scan iterates over all Redis keys matching the pattern using the SCAN cursor,
+// calling fn with each batch of keys as it is returned.
+// SCAN does not block Redis like KEYS does, but a key may be reported more than once
+// if the keyspace changes during iteration.
+// Returns an error if a SCAN call or fn fails.
+func (r *redisCache) Scan(ctx context.Context, pattern string, fn func(keys []string) error) (err error) {
+	var cursor uint64
+	for {
+		keys, next, err := r.client.Scan(ctx, cursor, pattern, scanBatchSize).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			if err = fn(keys); err != nil {
+				return err
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// Export writes every key matching the pattern and its value to w as JSONL records
+// in the same `{"key": ..., "value": ...}` format read by ImportJSONL.
+// Values are decoded with the configured codec; values that cannot be decoded are exported as strings. Keys that expire during the export are skipped.
+// Returns the number of records written, or an error if scanning, retrieval or writing fails.
+func (r *redisCache) Export(ctx context.Context, pattern string, w io.Writer) (count int, err error) {
+	encoder := json.NewEncoder(w)
+	err = r.Scan(ctx, pattern, func(keys []string) error {
+		values, err := r.client.MGet(ctx, keys...).Result()
+		if err != nil {
+			return err
+		}
+		for i, value := range values {
+			str, ok := value.(string)
+			if !ok {
+				continue
+			}
+			record := &jsonlRecord{Key: keys[i], Value: str}
+			var decoded SingleDataRecord
+			if err = r.opts.codec.Unmarshal([]byte(str), &decoded); err == nil {
+				record.Value = decoded
+			}
+			if err = encoder.Encode(record); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Count returns an approximate number of Redis keys matching the pattern, without loading their values.
+// It walks the whole keyspace with the SCAN cursor, so it is O(N) in the total number of keys
+// and should be used sparingly, e.g. for periodic capacity monitoring rather than per request.
+// Keys added or removed during the scan may or may not be counted.
+// Returns an error if a SCAN call fails.
+func (r *redisCache) Count(ctx context.Context, pattern string) (count int64, err error) {
+	err = r.Scan(ctx, pattern, func(keys []string) error {
+		count += int64(len(keys))
+		return nil
+	})
+	return count, err
+}
+
+// DeletePattern deletes every Redis key matching the pattern, e.g. "user:*" after a deploy.
+// Keys are found with SCAN, so Redis is never blocked the way KEYS would block it,
+// and each batch is removed with a single DEL.
+// Keys written during the deletion may or may not be removed.
+// Returns the number of keys deleted, or an error if a SCAN or DEL call fails.
+func (r *redisCache) DeletePattern(ctx context.Context, pattern string) (deleted int64, err error) {
+	err = r.Scan(ctx, pattern, func(keys []string) error {
+		n, err := r.client.Del(ctx, keys...).Result()
+		deleted += n
+		return err
+	})
+	return deleted, err
+}
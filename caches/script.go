@@ -0,0 +1,24 @@
+package caches
+
+import (
+	"context"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// scripts caches the compiled redis.Script for each Lua source, keyed by the source itself,
+// so every script's SHA1 is computed once and reused for EVALSHA.
+var scripts sync.Map
+
+// runScript executes a Lua script against Redis.
+// It tries EVALSHA with the script's cached SHA1 first and falls back to EVAL,
+// which loads the script into the Redis script cache, only when Redis reports NOSCRIPT.
+// Returns the raw script result, or an error if the execution fails (redis.Nil for a nil reply).
+func (r *redisCache) runScript(ctx context.Context, script string, keys []string, args ...interface{}) (result interface{}, err error) {
+	s, ok := scripts.Load(script)
+	if !ok {
+		s, _ = scripts.LoadOrStore(script, redis.NewScript(script))
+	}
+	return s.(*redis.Script).Run(ctx, r.client, keys, args...).Result()
+}
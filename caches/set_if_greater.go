@@ -0,0 +1,37 @@
+package caches
+
+import (
+	"context"
+	"strconv"
+)
+
+// setIfGreaterScript sets KEYS[1] to ARGV[1] only when the key is missing or holds a smaller number.
+// The key's remaining TTL is kept when it is updated.
+const setIfGreaterScript = `
+local current = redis.call('GET', KEYS[1])
+if current then
+	local number = tonumber(current)
+	if not number then
+		return redis.error_reply('ERR value is not a number')
+	end
+	if tonumber(ARGV[1]) <= number then
+		return 0
+	end
+	redis.call('SET', KEYS[1], ARGV[1], 'KEEPTTL')
+	return 1
+end
+redis.call('SET', KEYS[1], ARGV[1])
+return 1
+`
+
+// SetIfGreater atomically sets the key to value only if the key is missing or its current value is lower,
+// which tracks high-water marks such as the last seen sequence number across instances without a read-compare-write race.
+// The value is stored as a plain number, which GetSingle decodes with the default JSON codec.
+// Returns whether the update happened, or an error if the current value is not a number or the script execution fails.
+func (r *redisCache) SetIfGreater(ctx context.Context, key string, value float64) (updated bool, err error) {
+	result, err := r.runScript(ctx, setIfGreaterScript, []string{key}, strconv.FormatFloat(value, 'f', -1, 64))
+	if err != nil {
+		return false, err
+	}
+	return result == int64(1), nil
+}
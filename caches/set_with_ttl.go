@@ -0,0 +1,111 @@
+package caches
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+// SetSingleWithTTL stores a single data record in Redis with the specified key and expiration.
+// The value is validated and encoded with the configured codec; a ttl of 0 means no expiration.
+// Returns an error if validation, encoding or the storage operation fails.
+func (r *redisCache) SetSingleWithTTL(ctx context.Context, key string, value SingleDataRecord, ttl time.Duration) (err error) {
+	if err = r.opts.validate(value); err != nil {
+		return err
+	}
+	result, err := r.opts.codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(ctx, key, result, r.opts.jitter(ttl)).Err()
+}
+
+// SetNX stores a single data record in Redis only if the key does not exist yet, using SET NX.
+// The value is validated and encoded with the configured codec; a ttl of 0 means no expiration.
+// Returns whether the value was stored, or an error if validation, encoding or the storage operation fails.
+func (r *redisCache) SetNX(ctx context.Context, key string, value SingleDataRecord, ttl time.Duration) (stored bool, err error) {
+	if err = r.opts.validate(value); err != nil {
+		return false, err
+	}
+	result, err := r.opts.codec.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	return r.client.SetNX(ctx, key, result, ttl).Result()
+}
+
+// SetSingleWithTTL stores a single data record in Memcache with the specified key and expiration.
+// The value is validated and encoded with the configured codec; a ttl of 0 means no expiration.
+// Returns an error if validation, encoding or storage fails.
+func (m *memcacheCache) SetSingleWithTTL(ctx context.Context, key string, value SingleDataRecord, ttl time.Duration) (err error) {
+	if err = m.opts.validate(value); err != nil {
+		return err
+	}
+	data, err := m.opts.codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return m.client.Set(&memcache.Item{
+		Key:        key,
+		Value:      data,
+		Expiration: memcacheExpiration(m.opts.jitter(ttl)),
+	})
+}
+
+// SetNX stores a single data record in Memcache only if the key does not exist yet, using Add.
+// The value is validated and encoded with the configured codec; a ttl of 0 means no expiration.
+// Returns whether the value was stored, or an error if validation, encoding or storage fails.
+func (m *memcacheCache) SetNX(ctx context.Context, key string, value SingleDataRecord, ttl time.Duration) (stored bool, err error) {
+	if err = m.opts.validate(value); err != nil {
+		return false, err
+	}
+	data, err := m.opts.codec.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	err = m.client.Add(&memcache.Item{
+		Key:        key,
+		Value:      data,
+		Expiration: memcacheExpiration(ttl),
+	})
+	if err != nil {
+		if errors.Is(err, memcache.ErrNotStored) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// SetSingleWithTTL stores a single data record in memory with the specified key and expiration.
+// The value is validated and encoded with the configured codec; a ttl of 0 means no expiration.
+// Returns an error if validation or encoding fails.
+func (m *inMemoryCache) SetSingleWithTTL(ctx context.Context, key string, value SingleDataRecord, ttl time.Duration) (err error) {
+	if err = m.opts.validate(value); err != nil {
+		return err
+	}
+	return m.set(key, value, m.opts.jitter(ttl))
+}
+
+// SetNX stores a single data record in memory only if the key does not exist yet or has expired.
+// The value is validated and encoded with the configured codec; a ttl of 0 means no expiration.
+// Returns whether the value was stored, or an error if validation or encoding fails.
+func (m *inMemoryCache) SetNX(ctx context.Context, key string, value SingleDataRecord, ttl time.Duration) (stored bool, err error) {
+	if err = m.opts.validate(value); err != nil {
+		return false, err
+	}
+	data, err := m.opts.codec.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if elem, ok := m.entries[key]; ok && !elem.Value.(*inMemoryEntry).expired(m.opts.clock.Now()) {
+		return false, nil
+	}
+	m.store(key, data, ttl)
+	return true, nil
+}
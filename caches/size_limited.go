@@ -0,0 +1,178 @@
+package caches
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var (
+	// ErrValueTooLarge is returned when a value's encoding exceeds the limit set with NewSizeLimited.
+	ErrValueTooLarge = errors.New("cache: value too large")
+)
+
+var (
+	_ Cache    = &sizeLimitedCache{}
+	_ Pipeline = &sizeLimitedPipeline{}
+)
+
+type (
+	// sizeLimitedCache rejects writes whose encoded value is larger than maxBytes before they reach the wrapped cache.
+	sizeLimitedCache struct {
+		Cache
+		maxBytes int
+		codec    Codec
+	}
+
+	// sizeLimitedPipeline rejects oversized Set commands without queueing them on the wrapped pipeline.
+	sizeLimitedPipeline struct {
+		pipeline Pipeline
+		cache    *sizeLimitedCache
+		rejected []*Result // per queued command, the result of a rejected Set, nil for queued commands
+	}
+)
+
+// check returns ErrValueTooLarge if the encoded value exceeds the limit.
+func (s *sizeLimitedCache) check(key string, value interface{}) (err error) {
+	data, err := s.codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if len(data) > s.maxBytes {
+		return fmt.Errorf("%w: key %s is %d bytes, limit is %d", ErrValueTooLarge, key, len(data), s.maxBytes)
+	}
+	return nil
+}
+
+// SetSingle stores the value if it is within the limit.
+func (s *sizeLimitedCache) SetSingle(ctx context.Context, key string, value SingleDataRecord) (err error) {
+	if err = s.check(key, value); err != nil {
+		return err
+	}
+	return s.Cache.SetSingle(ctx, key, value)
+}
+
+// SetSingleWithTTL stores the value with the TTL if it is within the limit.
+func (s *sizeLimitedCache) SetSingleWithTTL(ctx context.Context, key string, value SingleDataRecord, ttl time.Duration) (err error) {
+	if err = s.check(key, value); err != nil {
+		return err
+	}
+	return s.Cache.SetSingleWithTTL(ctx, key, value, ttl)
+}
+
+// SetNX stores the value if the key is missing and the value is within the limit.
+func (s *sizeLimitedCache) SetNX(ctx context.Context, key string, value SingleDataRecord, ttl time.Duration) (stored bool, err error) {
+	if err = s.check(key, value); err != nil {
+		return false, err
+	}
+	return s.Cache.SetNX(ctx, key, value, ttl)
+}
+
+// SetMultiple stores the values if their combined encoding is within the limit.
+func (s *sizeLimitedCache) SetMultiple(ctx context.Context, key string, value MultipleDataRecord) (err error) {
+	if err = s.check(key, value); err != nil {
+		return err
+	}
+	return s.Cache.SetMultiple(ctx, key, value)
+}
+
+// SetMultipleIndexed stores the elements if every one of them is within the limit.
+// Nothing is written when any element is too large.
+func (s *sizeLimitedCache) SetMultipleIndexed(ctx context.Context, keyFunc func(item interface{}) string, values MultipleDataRecord, ttl time.Duration) (err error) {
+	for _, value := range values {
+		if err = s.check(keyFunc(value), value); err != nil {
+			return err
+		}
+	}
+	return s.Cache.SetMultipleIndexed(ctx, keyFunc, values, ttl)
+}
+
+// ImportJSONL imports the records, stopping at the first one whose value exceeds the limit.
+// Records before it have already been imported.
+func (s *sizeLimitedCache) ImportJSONL(ctx context.Context, r io.Reader, ttl time.Duration) (count int, err error) {
+	pr, pw := io.Pipe()
+	defer pr.Close()
+	go func() {
+		encoder := json.NewEncoder(pw)
+		_, err := readJSONL(r, func(record *jsonlRecord) error {
+			if err := s.check(record.Key, record.Value); err != nil {
+				return err
+			}
+			return encoder.Encode(record)
+		})
+		pw.CloseWithError(err)
+	}()
+	return s.Cache.ImportJSONL(ctx, pr, ttl)
+}
+
+// MergePatch applies the patch if the patch document itself is within the limit.
+// The size of the patched document is not checked, since it is only known to the backend.
+func (s *sizeLimitedCache) MergePatch(ctx context.Context, key string, patch json.RawMessage, ttl time.Duration) (err error) {
+	if len(patch) > s.maxBytes {
+		return fmt.Errorf("%w: patch for key %s is %d bytes, limit is %d", ErrValueTooLarge, key, len(patch), s.maxBytes)
+	}
+	return s.Cache.MergePatch(ctx, key, patch, ttl)
+}
+
+// Pipeline returns a builder that rejects oversized Set commands.
+func (s *sizeLimitedCache) Pipeline() (pipeline Pipeline) {
+	return &sizeLimitedPipeline{pipeline: s.Cache.Pipeline(), cache: s}
+}
+
+// Set queues storing the value, or records ErrValueTooLarge as the command's Result if it exceeds the limit.
+func (p *sizeLimitedPipeline) Set(key string, value SingleDataRecord, ttl time.Duration) Pipeline {
+	if err := p.cache.check(key, value); err != nil {
+		p.rejected = append(p.rejected, &Result{Key: key, Err: err})
+		return p
+	}
+	p.pipeline.Set(key, value, ttl)
+	p.rejected = append(p.rejected, nil)
+	return p
+}
+
+// Get queues retrieving the value.
+func (p *sizeLimitedPipeline) Get(key string) Pipeline {
+	p.pipeline.Get(key)
+	p.rejected = append(p.rejected, nil)
+	return p
+}
+
+// Delete queues removing the key.
+func (p *sizeLimitedPipeline) Delete(key string) Pipeline {
+	p.pipeline.Delete(key)
+	p.rejected = append(p.rejected, nil)
+	return p
+}
+
+// Exec runs the queued commands, merging the results of rejected Set commands back in queue order.
+func (p *sizeLimitedPipeline) Exec(ctx context.Context) (results []Result, err error) {
+	executed, err := p.pipeline.Exec(ctx)
+	results = make([]Result, 0, len(p.rejected))
+	for _, rejected := range p.rejected {
+		if rejected != nil {
+			results = append(results, *rejected)
+			continue
+		}
+		if len(executed) > 0 {
+			results = append(results, executed[0])
+			executed = executed[1:]
+		}
+	}
+	p.rejected = nil
+	return results, err
+}
+
+// NewSizeLimited wraps the cache so writes whose encoded value exceeds maxBytes fail with ErrValueTooLarge
+// before reaching the backend, instead of a backend-specific rejection such as Memcache's 1MB item limit.
+// Sizes are measured as JSON; multiple records are measured as a whole,
+// while SetMultipleIndexed measures each element. Reads are passed through unchanged.
+func NewSizeLimited(cache Cache, maxBytes int) Cache {
+	return &sizeLimitedCache{
+		Cache:    cache,
+		maxBytes: maxBytes,
+		codec:    JSONCodec{},
+	}
+}
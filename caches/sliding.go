@@ -0,0 +1,78 @@
+package caches
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/redis/go-redis/v9"
+)
+
+// GetSingleSliding retrieves a single data record from Redis and, on a hit, resets its expiration to ttl,
+// so keys that keep being read, such as active sessions, stay alive. GET and PEXPIRE are sent in one pipeline.
+// A ttl of 0 or less reads the key without changing its expiration.
+// Returns ErrNotFound if the key does not exist, or an error if retrieval or decoding fails.
+func (r *redisCache) GetSingleSliding(ctx context.Context, key string, ttl time.Duration) (result SingleDataRecord, err error) {
+	if ttl <= 0 {
+		return r.GetSingle(ctx, key)
+	}
+	var get *redis.StringCmd
+	_, err = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, key)
+		pipe.PExpire(ctx, key, ttl)
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if err = decode(r.opts.codec, key, []byte(get.Val()), &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetSingleSliding retrieves a single data record from Memcache and, on a hit, resets its expiration to ttl with Touch.
+// A ttl of 0 or less reads the key without changing its expiration.
+// As with GetSingle, it returns the raw byte data from the cache.
+// Returns ErrNotFound if the key does not exist, or an error if retrieval or the touch fails.
+func (m *memcacheCache) GetSingleSliding(ctx context.Context, key string, ttl time.Duration) (result SingleDataRecord, err error) {
+	item, err := m.client.Get(key)
+	if err != nil {
+		if errors.Is(err, memcache.ErrCacheMiss) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if ttl > 0 {
+		if err = m.client.Touch(key, memcacheExpiration(ttl)); err != nil {
+			if errors.Is(err, memcache.ErrCacheMiss) {
+				return nil, ErrNotFound
+			}
+			return nil, err
+		}
+	}
+	return item.Value, nil
+}
+
+// GetSingleSliding retrieves a single data record from memory and, on a hit, resets its expiration to ttl.
+// A ttl of 0 or less reads the key without changing its expiration.
+// Returns ErrNotFound if the key does not exist or has expired, or an error if decoding fails.
+func (m *inMemoryCache) GetSingleSliding(ctx context.Context, key string, ttl time.Duration) (result SingleDataRecord, err error) {
+	m.mu.Lock()
+	value, err := m.getLocked(key)
+	if err == nil && ttl > 0 {
+		m.entries[key].Value.(*inMemoryEntry).expiresAt = m.opts.clock.Now().Add(ttl)
+	}
+	m.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if err = decode(m.opts.codec, key, value, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,126 @@
+package caches
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"time"
+)
+
+var _ Cache = &slowLogCache{}
+
+// slowLogCache times every operation of the wrapped cache and logs the ones slower than threshold.
+// Pipeline is passed through untimed; its commands run when Exec is called on the returned builder.
+type slowLogCache struct {
+	Cache
+	threshold time.Duration
+	logger    *slog.Logger
+}
+
+// observe logs a warning if the operation started at started has taken longer than the threshold.
+// It is meant to be deferred at the start of each operation.
+func (s *slowLogCache) observe(ctx context.Context, operation, key string, started time.Time) {
+	elapsed := time.Since(started)
+	if elapsed <= s.threshold {
+		return
+	}
+	s.logger.WarnContext(ctx, "slow cache operation",
+		slog.String("operation", operation),
+		slog.String("key", key),
+		slog.Duration("duration", elapsed),
+	)
+}
+
+// SetSingle stores the value in the wrapped cache, logging the call if it is slow.
+func (s *slowLogCache) SetSingle(ctx context.Context, key string, value SingleDataRecord) (err error) {
+	defer s.observe(ctx, "SetSingle", key, time.Now())
+	return s.Cache.SetSingle(ctx, key, value)
+}
+
+// GetSingle reads the value from the wrapped cache, logging the call if it is slow.
+func (s *slowLogCache) GetSingle(ctx context.Context, key string) (result SingleDataRecord, err error) {
+	defer s.observe(ctx, "GetSingle", key, time.Now())
+	return s.Cache.GetSingle(ctx, key)
+}
+
+// SetSingleWithTTL stores the value with a TTL in the wrapped cache, logging the call if it is slow.
+func (s *slowLogCache) SetSingleWithTTL(ctx context.Context, key string, value SingleDataRecord, ttl time.Duration) (err error) {
+	defer s.observe(ctx, "SetSingleWithTTL", key, time.Now())
+	return s.Cache.SetSingleWithTTL(ctx, key, value, ttl)
+}
+
+// SetNX stores the value if the key is missing in the wrapped cache, logging the call if it is slow.
+func (s *slowLogCache) SetNX(ctx context.Context, key string, value SingleDataRecord, ttl time.Duration) (stored bool, err error) {
+	defer s.observe(ctx, "SetNX", key, time.Now())
+	return s.Cache.SetNX(ctx, key, value, ttl)
+}
+
+// SetMultiple stores the values in the wrapped cache, logging the call if it is slow.
+func (s *slowLogCache) SetMultiple(ctx context.Context, key string, value MultipleDataRecord) (err error) {
+	defer s.observe(ctx, "SetMultiple", key, time.Now())
+	return s.Cache.SetMultiple(ctx, key, value)
+}
+
+// GetMultiple reads the values from the wrapped cache, logging the call if it is slow.
+func (s *slowLogCache) GetMultiple(ctx context.Context, key string) (result MultipleDataRecord, err error) {
+	defer s.observe(ctx, "GetMultiple", key, time.Now())
+	return s.Cache.GetMultiple(ctx, key)
+}
+
+// GetAndDelete reads and removes the value from the wrapped cache, logging the call if it is slow.
+func (s *slowLogCache) GetAndDelete(ctx context.Context, key string) (result SingleDataRecord, err error) {
+	defer s.observe(ctx, "GetAndDelete", key, time.Now())
+	return s.Cache.GetAndDelete(ctx, key)
+}
+
+// ImportJSONL imports the records into the wrapped cache, logging the call if it is slow.
+func (s *slowLogCache) ImportJSONL(ctx context.Context, r io.Reader, ttl time.Duration) (count int, err error) {
+	defer s.observe(ctx, "ImportJSONL", "", time.Now())
+	return s.Cache.ImportJSONL(ctx, r, ttl)
+}
+
+// Delete removes the key from the wrapped cache, logging the call if it is slow.
+func (s *slowLogCache) Delete(ctx context.Context, key string) (err error) {
+	defer s.observe(ctx, "Delete", key, time.Now())
+	return s.Cache.Delete(ctx, key)
+}
+
+// GetMultipleInto reads and decodes the values from the wrapped cache, logging the call if it is slow.
+func (s *slowLogCache) GetMultipleInto(ctx context.Context, key string, newItem func() interface{}) (result MultipleDataRecord, err error) {
+	defer s.observe(ctx, "GetMultipleInto", key, time.Now())
+	return s.Cache.GetMultipleInto(ctx, key, newItem)
+}
+
+// SetMultipleIndexed stores each element in the wrapped cache, logging the call if it is slow.
+func (s *slowLogCache) SetMultipleIndexed(ctx context.Context, keyFunc func(item interface{}) string, values MultipleDataRecord, ttl time.Duration) (err error) {
+	defer s.observe(ctx, "SetMultipleIndexed", "", time.Now())
+	return s.Cache.SetMultipleIndexed(ctx, keyFunc, values, ttl)
+}
+
+// MergePatch patches the document in the wrapped cache, logging the call if it is slow.
+func (s *slowLogCache) MergePatch(ctx context.Context, key string, patch json.RawMessage, ttl time.Duration) (err error) {
+	defer s.observe(ctx, "MergePatch", key, time.Now())
+	return s.Cache.MergePatch(ctx, key, patch, ttl)
+}
+
+// Ping checks the wrapped cache, logging the call if it is slow.
+func (s *slowLogCache) Ping(ctx context.Context) (err error) {
+	defer s.observe(ctx, "Ping", "", time.Now())
+	return s.Cache.Ping(ctx)
+}
+
+// NewSlowLogger wraps the cache so every operation taking longer than threshold is logged as a warning
+// with the operation name, key and duration. Fast operations are not logged.
+// Operations that do not act on a single key, such as ImportJSONL, are logged with an empty key.
+// A nil logger logs to slog.Default().
+func NewSlowLogger(cache Cache, threshold time.Duration, logger *slog.Logger) Cache {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &slowLogCache{
+		Cache:     cache,
+		threshold: threshold,
+		logger:    logger,
+	}
+}
@@ -0,0 +1,205 @@
+package caches
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+var (
+	_ Cache       = &tieredCache{}
+	_ TieredCache = &tieredCache{}
+)
+
+type (
+	// TieredCache is a two-level cache: a fast local L1 in front of a shared L2.
+	TieredCache interface {
+		Cache
+
+		// Close stops listening for invalidations from other instances.
+		Close() (err error)
+	}
+
+	// tieredCache reads through a local L1 to a shared L2 and invalidates the L1 on writes.
+	// Operations it does not override, such as ImportJSONL and Pipeline, go to the L2 only.
+	tieredCache struct {
+		Cache          // the shared L2
+		local    Cache // the per-instance L1
+		localTTL time.Duration
+		// broadcast tells other instances to drop a key from their L1, nil without invalidation.
+		broadcast   func(ctx context.Context, key string) error
+		unsubscribe func() error
+	}
+)
+
+// GetSingle returns the value from the L1, or reads it from the L2 and keeps it in the L1 for localTTL.
+func (t *tieredCache) GetSingle(ctx context.Context, key string) (result SingleDataRecord, err error) {
+	localKey := storageKey(t.Cache, key)
+	if result, err = t.local.GetSingle(ctx, localKey); err == nil {
+		return result, nil
+	}
+	if result, err = t.Cache.GetSingle(ctx, key); err != nil {
+		return nil, err
+	}
+	_ = t.local.SetSingleWithTTL(ctx, localKey, result, t.localTTL)
+	return result, nil
+}
+
+// Lookup returns the value through GetSingle, reporting a miss in both levels through found.
+func (t *tieredCache) Lookup(ctx context.Context, key string) (value SingleDataRecord, found bool, err error) {
+	return lookup(t.GetSingle(ctx, key))
+}
+
+// GetMultiple returns the values from the L1, or reads them from the L2 and keeps them in the L1 for localTTL.
+func (t *tieredCache) GetMultiple(ctx context.Context, key string) (result MultipleDataRecord, err error) {
+	localKey := storageKey(t.Cache, key)
+	if result, err = t.local.GetMultiple(ctx, localKey); err == nil {
+		return result, nil
+	}
+	if result, err = t.Cache.GetMultiple(ctx, key); err != nil {
+		return nil, err
+	}
+	_ = t.local.SetSingleWithTTL(ctx, localKey, result, t.localTTL)
+	return result, nil
+}
+
+// SetSingle stores the value in the L2 and invalidates the key in every L1.
+func (t *tieredCache) SetSingle(ctx context.Context, key string, value SingleDataRecord) (err error) {
+	if err = t.Cache.SetSingle(ctx, key, value); err != nil {
+		return err
+	}
+	return t.invalidate(ctx, key)
+}
+
+// SetSingleWithTTL stores the value in the L2 with the TTL and invalidates the key in every L1.
+func (t *tieredCache) SetSingleWithTTL(ctx context.Context, key string, value SingleDataRecord, ttl time.Duration) (err error) {
+	if err = t.Cache.SetSingleWithTTL(ctx, key, value, ttl); err != nil {
+		return err
+	}
+	return t.invalidate(ctx, key)
+}
+
+// SetNX stores the value in the L2 if the key is missing there, and invalidates the key in every L1 if it was stored.
+func (t *tieredCache) SetNX(ctx context.Context, key string, value SingleDataRecord, ttl time.Duration) (stored bool, err error) {
+	if stored, err = t.Cache.SetNX(ctx, key, value, ttl); err != nil || !stored {
+		return stored, err
+	}
+	return true, t.invalidate(ctx, key)
+}
+
+// SetMultiple stores the values in the L2 and invalidates the key in every L1.
+func (t *tieredCache) SetMultiple(ctx context.Context, key string, value MultipleDataRecord) (err error) {
+	if err = t.Cache.SetMultiple(ctx, key, value); err != nil {
+		return err
+	}
+	return t.invalidate(ctx, key)
+}
+
+// SetMultipleIndexed stores the elements in the L2 and invalidates each element's key in every L1.
+func (t *tieredCache) SetMultipleIndexed(ctx context.Context, keyFunc func(item interface{}) string, values MultipleDataRecord, ttl time.Duration) (err error) {
+	if err = t.Cache.SetMultipleIndexed(ctx, keyFunc, values, ttl); err != nil {
+		return err
+	}
+	for _, value := range values {
+		if err = t.invalidate(ctx, keyFunc(value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetAndDelete reads and removes the value from the L2 and invalidates the key in every L1.
+func (t *tieredCache) GetAndDelete(ctx context.Context, key string) (result SingleDataRecord, err error) {
+	if result, err = t.Cache.GetAndDelete(ctx, key); err != nil {
+		return nil, err
+	}
+	return result, t.invalidate(ctx, key)
+}
+
+// MergePatch patches the document in the L2 and invalidates the key in every L1.
+func (t *tieredCache) MergePatch(ctx context.Context, key string, patch json.RawMessage, ttl time.Duration) (err error) {
+	if err = t.Cache.MergePatch(ctx, key, patch, ttl); err != nil {
+		return err
+	}
+	return t.invalidate(ctx, key)
+}
+
+// Delete removes the key from the L2 and invalidates it in every L1.
+func (t *tieredCache) Delete(ctx context.Context, key string) (err error) {
+	if err = t.Cache.Delete(ctx, key); err != nil {
+		return err
+	}
+	return t.invalidate(ctx, key)
+}
+
+// Close stops listening for invalidations from other instances.
+func (t *tieredCache) Close() (err error) {
+	if t.unsubscribe == nil {
+		return nil
+	}
+	return t.unsubscribe()
+}
+
+// invalidate drops the key from the local L1 and tells the other instances to do the same.
+// The L1 and the broadcast use the key as the L2 stores it, so a key transformed by a decorator such as
+// NewHashedKeys is never broadcast in plaintext and every instance evicts the same entry.
+func (t *tieredCache) invalidate(ctx context.Context, key string) (err error) {
+	localKey := storageKey(t.Cache, key)
+	if err = t.local.Delete(ctx, localKey); err != nil {
+		return err
+	}
+	if t.broadcast == nil {
+		return nil
+	}
+	return t.broadcast(ctx, localKey)
+}
+
+// NewTiered puts a local L1, typically NewInMemory, in front of a shared L2 such as Redis.
+// Reads are served from the L1 when possible and otherwise read from the L2 and kept in the L1 for localTTL.
+// Writes and deletes go to the L2 and drop the key from the L1, but other instances keep their L1 copy
+// until it expires; use NewTieredWithInvalidation to evict it everywhere.
+func NewTiered(local, remote Cache, localTTL time.Duration) TieredCache {
+	return &tieredCache{
+		Cache:    remote,
+		local:    local,
+		localTTL: localTTL,
+	}
+}
+
+// NewTieredWithInvalidation is like NewTiered, but every write or delete is also broadcast on the
+// pub/sub channel of bus, typically the Redis backend, so all instances sharing the channel evict the key from their L1.
+// The remote cache may be a decorator such as NewHashedKeys over Redis; keys are broadcast in the form the L2 stores them.
+// Invalidations published by this instance are ignored when they come back, since its L1 is already up to date.
+// Pub/sub is fire-and-forget: an instance that is disconnected when an invalidation is sent keeps its copy
+// until localTTL expires, so localTTL bounds staleness. Call Close to stop listening.
+// Returns an error if subscribing to the channel fails.
+func NewTieredWithInvalidation(ctx context.Context, local, remote Cache, localTTL time.Duration, bus PubSub, channel string) (cache TieredCache, err error) {
+	id := make([]byte, 8)
+	if _, err = rand.Read(id); err != nil {
+		return nil, err
+	}
+	instance := hex.EncodeToString(id)
+
+	tiered := &tieredCache{
+		Cache:    remote,
+		local:    local,
+		localTTL: localTTL,
+		broadcast: func(ctx context.Context, key string) error {
+			return bus.Publish(ctx, channel, instance+" "+key)
+		},
+	}
+	tiered.unsubscribe, err = bus.Subscribe(ctx, channel, func(message string) {
+		sender, key, ok := strings.Cut(message, " ")
+		if !ok || sender == instance {
+			return
+		}
+		_ = local.Delete(context.Background(), key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tiered, nil
+}
@@ -0,0 +1,232 @@
+package caches
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+var (
+	_ Cache    = &transformCache{}
+	_ Pipeline = &transformPipeline{}
+)
+
+type (
+	// transformCache rewrites values on their way to and from the wrapped cache, e.g. to encrypt or compress them.
+	// Records are sealed as a whole, so multiple records are stored with SetSingle and read with GetSingle.
+	transformCache struct {
+		Cache
+		seal  func(value interface{}) (stored SingleDataRecord, err error)  // turns a value into what the backend stores
+		open  func(stored SingleDataRecord, target interface{}) (err error) // reverses seal, decoding into target
+		codec Codec                                                         // decodes elements for GetMultipleInto
+		// mergePatchErr is returned by MergePatch, since the backend cannot patch sealed values.
+		mergePatchErr error
+	}
+
+	// transformPipeline seals queued writes and opens the results of queued reads.
+	transformPipeline struct {
+		pipeline Pipeline
+		cache    *transformCache
+		rejected []*Result // per queued command, the result of a Set that failed to seal, nil for queued commands
+	}
+)
+
+// SetSingle seals the value and stores it.
+func (t *transformCache) SetSingle(ctx context.Context, key string, value SingleDataRecord) (err error) {
+	stored, err := t.seal(value)
+	if err != nil {
+		return err
+	}
+	return t.Cache.SetSingle(ctx, key, stored)
+}
+
+// GetSingle reads and opens the value.
+func (t *transformCache) GetSingle(ctx context.Context, key string) (result SingleDataRecord, err error) {
+	stored, err := t.Cache.GetSingle(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if err = t.open(stored, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Lookup reads and opens the value, reporting a miss through found.
+func (t *transformCache) Lookup(ctx context.Context, key string) (value SingleDataRecord, found bool, err error) {
+	return lookup(t.GetSingle(ctx, key))
+}
+
+// SetSingleWithTTL seals the value and stores it with the TTL.
+func (t *transformCache) SetSingleWithTTL(ctx context.Context, key string, value SingleDataRecord, ttl time.Duration) (err error) {
+	stored, err := t.seal(value)
+	if err != nil {
+		return err
+	}
+	return t.Cache.SetSingleWithTTL(ctx, key, stored, ttl)
+}
+
+// SetNX seals the value and stores it if the key does not exist yet.
+func (t *transformCache) SetNX(ctx context.Context, key string, value SingleDataRecord, ttl time.Duration) (stored bool, err error) {
+	sealed, err := t.seal(value)
+	if err != nil {
+		return false, err
+	}
+	return t.Cache.SetNX(ctx, key, sealed, ttl)
+}
+
+// SetMultiple seals the values as a whole and stores them.
+func (t *transformCache) SetMultiple(ctx context.Context, key string, value MultipleDataRecord) (err error) {
+	stored, err := t.seal(value)
+	if err != nil {
+		return err
+	}
+	return t.Cache.SetSingle(ctx, key, stored)
+}
+
+// GetMultiple reads and opens the values.
+func (t *transformCache) GetMultiple(ctx context.Context, key string) (result MultipleDataRecord, err error) {
+	stored, err := t.Cache.GetSingle(ctx, key)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if err = t.open(stored, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetMultipleInto reads and opens the values, decoding each element into a value from newItem.
+func (t *transformCache) GetMultipleInto(ctx context.Context, key string, newItem func() interface{}) (result MultipleDataRecord, err error) {
+	if !splitsArrays(t.codec) {
+		values, err := t.GetMultiple(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		return decodeValues(t.codec, values, newItem)
+	}
+	stored, err := t.Cache.GetSingle(ctx, key)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	raw := &rawElements{}
+	if err = t.open(stored, raw); err != nil {
+		return nil, err
+	}
+	return decodeElements(raw.codec(t.codec), raw.items, newItem)
+}
+
+// GetAndDelete reads, removes and opens the value.
+func (t *transformCache) GetAndDelete(ctx context.Context, key string) (result SingleDataRecord, err error) {
+	stored, err := t.Cache.GetAndDelete(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if err = t.open(stored, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ImportJSONL seals each record's value before importing it.
+func (t *transformCache) ImportJSONL(ctx context.Context, r io.Reader, ttl time.Duration) (count int, err error) {
+	pr, pw := io.Pipe()
+	defer pr.Close()
+	go func() {
+		encoder := json.NewEncoder(pw)
+		_, err := readJSONL(r, func(record *jsonlRecord) error {
+			stored, err := t.seal(record.Value)
+			if err != nil {
+				return err
+			}
+			record.Value = stored
+			return encoder.Encode(record)
+		})
+		pw.CloseWithError(err)
+	}()
+	return t.Cache.ImportJSONL(ctx, pr, ttl)
+}
+
+// SetMultipleIndexed seals each element and stores it under the key derived by keyFunc.
+// The elements are written one by one, since keyFunc needs the unsealed element.
+func (t *transformCache) SetMultipleIndexed(ctx context.Context, keyFunc func(item interface{}) string, values MultipleDataRecord, ttl time.Duration) (err error) {
+	for _, value := range values {
+		if err = t.SetSingleWithTTL(ctx, keyFunc(value), value, ttl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MergePatch is not supported, since the backend only holds sealed values.
+func (t *transformCache) MergePatch(ctx context.Context, key string, patch json.RawMessage, ttl time.Duration) (err error) {
+	return t.mergePatchErr
+}
+
+// Pipeline returns a builder that seals queued writes and opens the results of queued reads.
+func (t *transformCache) Pipeline() (pipeline Pipeline) {
+	return &transformPipeline{pipeline: t.Cache.Pipeline(), cache: t}
+}
+
+// Set queues storing the sealed value. A sealing failure is reported in the command's Result
+// and nothing is queued, so the key keeps its current value.
+func (p *transformPipeline) Set(key string, value SingleDataRecord, ttl time.Duration) Pipeline {
+	stored, err := p.cache.seal(value)
+	if err != nil {
+		p.rejected = append(p.rejected, &Result{Key: key, Err: err})
+		return p
+	}
+	p.pipeline.Set(key, stored, ttl)
+	p.rejected = append(p.rejected, nil)
+	return p
+}
+
+// Get queues retrieving the value, which is opened on Exec.
+func (p *transformPipeline) Get(key string) Pipeline {
+	p.pipeline.Get(key)
+	p.rejected = append(p.rejected, nil)
+	return p
+}
+
+// Delete queues removing the key.
+func (p *transformPipeline) Delete(key string) Pipeline {
+	p.pipeline.Delete(key)
+	p.rejected = append(p.rejected, nil)
+	return p
+}
+
+// Exec runs the queued commands, opening retrieved values
+// and merging the results of Set commands that failed to seal back in queue order.
+func (p *transformPipeline) Exec(ctx context.Context) (results []Result, err error) {
+	executed, err := p.pipeline.Exec(ctx)
+	results = make([]Result, 0, len(p.rejected))
+	for _, rejected := range p.rejected {
+		if rejected != nil {
+			results = append(results, *rejected)
+			continue
+		}
+		if len(executed) == 0 {
+			continue
+		}
+		result := executed[0]
+		executed = executed[1:]
+		if result.Err == nil && result.Value != nil {
+			var value SingleDataRecord
+			if result.Err = p.cache.open(result.Value, &value); result.Err == nil {
+				result.Value = value
+			} else {
+				result.Value = nil
+			}
+		}
+		results = append(results, result)
+	}
+	p.rejected = nil
+	return results, err
+}
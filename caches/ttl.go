@@ -0,0 +1,49 @@
+package caches
+
+import (
+	"context"
+	"time"
+)
+
+// NoExpiration is returned by TTL for keys that exist but never expire.
+const NoExpiration time.Duration = -1
+
+// TTL returns how long the key has left before it expires in Redis, with millisecond precision.
+// Returns NoExpiration if the key exists without an expiration, ErrNotFound if the key does not exist,
+// or an error if the command fails.
+func (r *redisCache) TTL(ctx context.Context, key string) (remaining time.Duration, err error) {
+	remaining, err = r.client.PTTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// Redis reports -2 for a missing key and -1 for a key without an expiration.
+	switch remaining {
+	case -2:
+		return 0, ErrNotFound
+	case -1:
+		return NoExpiration, nil
+	}
+	return remaining, nil
+}
+
+// TTL returns how long the key has left before it expires in memory, measured with the configured Clock.
+// Returns NoExpiration if the key exists without an expiration, or ErrNotFound if it does not exist or has expired.
+// Reading the TTL does not mark the key as recently used.
+func (m *inMemoryCache) TTL(ctx context.Context, key string) (remaining time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	elem, ok := m.entries[key]
+	if !ok {
+		return 0, ErrNotFound
+	}
+	entry := elem.Value.(*inMemoryEntry)
+	now := m.opts.clock.Now()
+	if entry.expired(now) {
+		m.removeElement(elem)
+		return 0, ErrNotFound
+	}
+	if entry.expiresAt.IsZero() {
+		return NoExpiration, nil
+	}
+	return entry.expiresAt.Sub(now), nil
+}
@@ -0,0 +1,57 @@
+package caches
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// NewFromURL creates a cache backend from a connection URL, so a single configuration string selects
+// and configures the backend:
+//
+//   - redis://[[user]:password@]host:port[/db] connects to Redis, authenticating and selecting db when given
+//   - rediss://... connects to Redis over TLS
+//   - memcache://host:port connects to Memcache
+//
+// The settings taken from the URL are applied before opts, so opts can override them.
+// Returns an error if the URL cannot be parsed or its scheme is not supported.
+func NewFromURL(rawURL string, opts ...Option) (cache Cache, err error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		// url.Error quotes the URL, which may hold a password, so only its cause is reported.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("cache: parse url: %w", err)
+	}
+
+	switch parsed.Scheme {
+	case "redis", "rediss":
+		redisOpts, err := redis.ParseURL(rawURL)
+		if err != nil {
+			return nil, fmt.Errorf("cache: parse url: %w", err)
+		}
+		host, port, err := net.SplitHostPort(redisOpts.Addr)
+		if err != nil {
+			return nil, fmt.Errorf("cache: parse url: %w", err)
+		}
+		urlOpts := []Option{
+			WithCredentials(redisOpts.Username, redisOpts.Password),
+			WithDatabase(redisOpts.DB),
+			WithTLS(redisOpts.TLSConfig),
+		}
+		return NewRedis(host, port, append(urlOpts, opts...)...), nil
+	case "memcache":
+		host, port := parsed.Hostname(), parsed.Port()
+		if host == "" || port == "" {
+			return nil, fmt.Errorf("cache: memcache url %q must name a host and port", parsed.Redacted())
+		}
+		return NewMemcache(host, port, opts...), nil
+	default:
+		return nil, fmt.Errorf("cache: unsupported url scheme %q", parsed.Scheme)
+	}
+}
@@ -0,0 +1,66 @@
+package health
+
+import (
+	"context"
+	"sync"
+)
+
+type (
+	// Pinger is implemented by any infrastructure component that can report whether it is reachable,
+	// such as the caches and the NSQ client.
+	Pinger interface {
+		// Ping checks the component and returns an error if it is unhealthy.
+		Ping(ctx context.Context) (err error)
+	}
+
+	// Component names a Pinger so its status can be told apart in a Report.
+	Component struct {
+		Name   string // The name shown in the report, e.g. "redis" or "nsq"
+		Pinger Pinger // The component being checked
+	}
+
+	// ComponentStatus is the outcome of checking a single component.
+	ComponentStatus struct {
+		Name    string `json:"name"`            // The component name
+		Healthy bool   `json:"healthy"`         // Whether Ping succeeded
+		Error   string `json:"error,omitempty"` // The Ping error, if any
+	}
+
+	// Report is the combined outcome of checking several components.
+	Report struct {
+		Healthy    bool              `json:"healthy"`    // Whether every component is healthy
+		Components []ComponentStatus `json:"components"` // Per-component status, in the order given to Check
+	}
+)
+
+// Check pings every component concurrently and combines the results into a Report.
+// The report is healthy only if every component's Ping succeeded.
+// Callers should bound the check with a context deadline so a hung component cannot block the probe.
+func Check(ctx context.Context, components ...Component) (report *Report) {
+	report = &Report{
+		Healthy:    true,
+		Components: make([]ComponentStatus, len(components)),
+	}
+
+	var wg sync.WaitGroup
+	for i, component := range components {
+		wg.Add(1)
+		go func(i int, component Component) {
+			defer wg.Done()
+			status := ComponentStatus{Name: component.Name, Healthy: true}
+			if err := component.Pinger.Ping(ctx); err != nil {
+				status.Healthy = false
+				status.Error = err.Error()
+			}
+			report.Components[i] = status
+		}(i, component)
+	}
+	wg.Wait()
+
+	for _, status := range report.Components {
+		if !status.Healthy {
+			report.Healthy = false
+		}
+	}
+	return report
+}
@@ -0,0 +1,108 @@
+package nsq
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultAdaptiveInterval is how often MaxInFlight is re-evaluated when no interval is configured.
+const defaultAdaptiveInterval = time.Second
+
+type (
+	// AdaptiveMaxInFlight configures how WithAdaptiveMaxInFlight tunes a consumer's MaxInFlight
+	// from the average handler latency.
+	AdaptiveMaxInFlight struct {
+		Min              int           // Lowest MaxInFlight to back off to, at least 1
+		Max              int           // Highest MaxInFlight, also the starting value
+		SlowThreshold    time.Duration // Average latency above which MaxInFlight is halved
+		RecoverThreshold time.Duration // Average latency below which MaxInFlight grows by one
+		Interval         time.Duration // How often the average is evaluated, 1s by default
+	}
+
+	// maxInFlightChanger is the subset of *nsq.Consumer used to apply a new MaxInFlight.
+	maxInFlightChanger interface {
+		ChangeMaxInFlight(maxInFlight int)
+	}
+
+	// inFlightController changes a consumer's MaxInFlight in the background until it is halted.
+	inFlightController interface {
+		halt()
+	}
+
+	// inFlightTuner averages handler latencies over each interval and adjusts MaxInFlight accordingly.
+	inFlightTuner struct {
+		cfg      AdaptiveMaxInFlight
+		consumer maxInFlightChanger
+
+		mu          sync.Mutex
+		current     int
+		total       time.Duration
+		count       int
+		windowStart time.Time
+		stopped     bool
+	}
+)
+
+// newInFlightTuner creates a tuner starting at cfg.Max, normalizing invalid bounds.
+func newInFlightTuner(cfg AdaptiveMaxInFlight, consumer maxInFlightChanger) *inFlightTuner {
+	if cfg.Min < 1 {
+		cfg.Min = 1
+	}
+	if cfg.Max < cfg.Min {
+		cfg.Max = cfg.Min
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultAdaptiveInterval
+	}
+	return &inFlightTuner{
+		cfg:         cfg,
+		consumer:    consumer,
+		current:     cfg.Max,
+		windowStart: time.Now(),
+	}
+}
+
+// start applies the initial MaxInFlight to the consumer.
+func (t *inFlightTuner) start() {
+	t.consumer.ChangeMaxInFlight(t.current)
+}
+
+// observe records one handler latency and, once per interval, lowers MaxInFlight when the
+// average latency is above SlowThreshold or raises it when below RecoverThreshold.
+func (t *inFlightTuner) observe(latency time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stopped {
+		return
+	}
+
+	t.total += latency
+	t.count++
+	now := time.Now()
+	if now.Sub(t.windowStart) < t.cfg.Interval {
+		return
+	}
+
+	average := t.total / time.Duration(t.count)
+	t.total, t.count, t.windowStart = 0, 0, now
+
+	next := t.current
+	switch {
+	case t.cfg.SlowThreshold > 0 && average > t.cfg.SlowThreshold:
+		next = max(t.current/2, t.cfg.Min)
+	case average < t.cfg.RecoverThreshold:
+		next = min(t.current+1, t.cfg.Max)
+	}
+	if next != t.current {
+		t.current = next
+		t.consumer.ChangeMaxInFlight(next)
+	}
+}
+
+// halt stops the tuner. Once it returns observations from handlers still running are ignored,
+// so Drain can set MaxInFlight to zero without it being raised again.
+func (t *inFlightTuner) halt() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopped = true
+}
@@ -0,0 +1,103 @@
+package nsq
+
+import (
+	"sync"
+	"time"
+)
+
+// Circuit breaker states.
+const (
+	breakerClosed = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+type (
+	// CircuitBreaker configures how WithCircuitBreaker pauses a consumer whose handler keeps failing.
+	CircuitBreaker struct {
+		Failures int           // Consecutive handler failures that open the breaker, at least 1
+		Cooldown time.Duration // How long consumption is paused before a single message is let through as a probe
+	}
+
+	// circuitBreaker pauses consumption by setting MaxInFlight to 0 after consecutive handler failures,
+	// then probes with a MaxInFlight of 1 after the cooldown. It stands in for the consumer as the
+	// maxInFlightChanger of tuners and ramps, so their changes are deferred while the breaker is not closed.
+	circuitBreaker struct {
+		cfg      CircuitBreaker
+		consumer maxInFlightChanger
+
+		mu       sync.Mutex
+		state    int
+		failures int         // consecutive failures while closed
+		desired  int         // MaxInFlight restored when the breaker closes
+		probe    *time.Timer // moves the open breaker to half-open
+		stopped  bool
+	}
+)
+
+// newCircuitBreaker creates a closed breaker that restores maxInFlight when it closes again.
+func newCircuitBreaker(cfg CircuitBreaker, maxInFlight int, consumer maxInFlightChanger) *circuitBreaker {
+	if cfg.Failures < 1 {
+		cfg.Failures = 1
+	}
+	return &circuitBreaker{cfg: cfg, consumer: consumer, desired: maxInFlight}
+}
+
+// record updates the breaker with the outcome of a handler.
+// A success while half-open closes the breaker and restores MaxInFlight; a failure while half-open,
+// or the configured number of consecutive failures while closed, opens it.
+// Outcomes of messages that were already in flight when the breaker opened are ignored.
+func (b *circuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stopped || b.state == breakerOpen {
+		return
+	}
+	if err == nil {
+		b.failures = 0
+		if b.state == breakerHalfOpen {
+			b.state = breakerClosed
+			b.consumer.ChangeMaxInFlight(b.desired)
+		}
+		return
+	}
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.cfg.Failures {
+		b.state = breakerOpen
+		b.failures = 0
+		b.consumer.ChangeMaxInFlight(0)
+		b.probe = time.AfterFunc(b.cfg.Cooldown, b.halfOpen)
+	}
+}
+
+// halfOpen lets a single message through to probe whether the handler has recovered.
+func (b *circuitBreaker) halfOpen() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stopped {
+		return
+	}
+	b.state = breakerHalfOpen
+	b.consumer.ChangeMaxInFlight(1)
+}
+
+// ChangeMaxInFlight records maxInFlight as the value to run at, applying it right away only while the breaker is closed.
+func (b *circuitBreaker) ChangeMaxInFlight(maxInFlight int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.desired = maxInFlight
+	if !b.stopped && b.state == breakerClosed {
+		b.consumer.ChangeMaxInFlight(maxInFlight)
+	}
+}
+
+// halt stops the breaker. Once it returns the breaker no longer changes MaxInFlight,
+// so Drain can set it to zero without being overridden by a pending probe.
+func (b *circuitBreaker) halt() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.stopped = true
+	if b.probe != nil {
+		b.probe.Stop()
+	}
+}
@@ -0,0 +1,23 @@
+package nsq
+
+import (
+	"context"
+	"github.com/RandySteven/common_go/broker"
+)
+
+// Broker adapts an NSQ client to the broker.MessageBroker interface.
+type Broker struct {
+	NSQ
+}
+
+// Publish sends a message to the specified topic.
+func (b *Broker) Publish(ctx context.Context, topic string, msg []byte) (err error) {
+	return b.NSQ.Publish(ctx, &NsqEvent{Topic: topic, Message: msg})
+}
+
+var _ broker.MessageBroker = &Broker{}
+
+// NewBroker wraps the NSQ client so it can be used wherever a broker.MessageBroker is expected.
+func NewBroker(client NSQ) broker.MessageBroker {
+	return &Broker{NSQ: client}
+}
@@ -0,0 +1,56 @@
+package nsq
+
+import (
+	"time"
+
+	"github.com/nsqio/go-nsq"
+)
+
+// connectionPollInterval is how often a consumer's connection count is checked for WithConnectionCallback.
+const connectionPollInterval = time.Second
+
+type (
+	// ConnectionEvent describes a change in the number of nsqd connections a consumer holds,
+	// as nsqd nodes join or leave the topology discovered through nsqlookupd.
+	ConnectionEvent struct {
+		Topic       string // The consumer's topic
+		Channel     string // The consumer's channel
+		Connections int    // How many nsqd connections the consumer holds now
+		Previous    int    // How many it held at the previous check
+	}
+
+	// ConnectionCallback is called with every change in a consumer's nsqd connections.
+	ConnectionCallback func(event ConnectionEvent)
+
+	// statsSource is the subset of *nsq.Consumer used to watch its connections.
+	statsSource interface {
+		Stats() *nsq.ConsumerStats
+	}
+)
+
+// Connected reports whether the consumer gained connections since the previous check.
+func (e ConnectionEvent) Connected() bool {
+	return e.Connections > e.Previous
+}
+
+// watchConnections polls the consumer's connection count every interval and calls cb whenever it changes,
+// until stop is closed. go-nsq does not expose its connection callbacks, so changes are detected by polling.
+func watchConnections(topic, channel string, consumer statsSource, interval time.Duration, stop <-chan int, cb ConnectionCallback) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	previous := 0
+	for {
+		select {
+		case <-stop:
+			if previous > 0 {
+				cb(ConnectionEvent{Topic: topic, Channel: channel, Connections: 0, Previous: previous})
+			}
+			return
+		case <-ticker.C:
+		}
+		if current := consumer.Stats().Connections; current != previous {
+			cb(ConnectionEvent{Topic: topic, Channel: channel, Connections: current, Previous: previous})
+			previous = current
+		}
+	}
+}
@@ -4,8 +4,12 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 )
 
+// receivedBufferSize is the number of unread messages kept per topic for ConsumeWait.
+const receivedBufferSize = 100
+
 // Consume retrieves a message from the specified topic by checking the context.
 // It looks for a value associated with the topic in the provided context.
 // If found, it returns the message as a string; otherwise, it returns an error.
@@ -19,3 +23,79 @@ func (c *Client) Consume(ctx context.Context, topic string) (value string, err e
 		return "", fmt.Errorf(`failed to consume the topic %s`, topic)
 	}
 }
+
+// ConsumeWait waits for a message on the specified topic, turning Consume into a pull API.
+// If the context already carries a message for the topic it is returned immediately, as with Consume.
+// Otherwise it blocks until a registered consumer receives a message for the topic,
+// the timeout elapses, or the context is done.
+// Messages are only buffered while a ConsumeWait call is waiting on the topic, up to the last 100 unread ones;
+// messages received while nobody waits are not kept, so a later call never returns stale messages.
+// Returns an error if no message arrived in time.
+func (c *Client) ConsumeWait(ctx context.Context, topic string, timeout time.Duration) (value string, err error) {
+	if value, ok := ctx.Value(topic).(string); ok {
+		return value, nil
+	}
+
+	received := c.waitReceived(topic)
+	defer c.stopWaiting(topic)
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case value = <-received:
+		return value, nil
+	case <-timer.C:
+		return "", fmt.Errorf(`timed out after %s waiting for topic %s`, timeout, topic)
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// offer buffers a received message body for ConsumeWait without blocking the handler,
+// if a ConsumeWait call is waiting on the topic. When the buffer is full the oldest message is dropped to make room.
+func (c *Client) offer(topic, body string) {
+	c.mu.Lock()
+	ch, ok := c.received[topic]
+	c.mu.Unlock()
+	if !ok {
+		return
+	}
+	for {
+		select {
+		case ch <- body:
+			return
+		default:
+		}
+		select {
+		case <-ch:
+		default:
+		}
+	}
+}
+
+// waitReceived registers a ConsumeWait call on the topic and returns the topic's buffer of received messages,
+// creating it if this is the first waiter.
+func (c *Client) waitReceived(topic string) chan string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.received == nil {
+		c.received = make(map[string]chan string)
+		c.waiters = make(map[string]int)
+	}
+	ch, ok := c.received[topic]
+	if !ok {
+		ch = make(chan string, receivedBufferSize)
+		c.received[topic] = ch
+	}
+	c.waiters[topic]++
+	return ch
+}
+
+// stopWaiting unregisters a ConsumeWait call on the topic, dropping the topic's buffer once no call waits on it.
+func (c *Client) stopWaiting(topic string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.waiters[topic]--; c.waiters[topic] <= 0 {
+		delete(c.waiters, topic)
+		delete(c.received, topic)
+	}
+}
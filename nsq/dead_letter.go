@@ -0,0 +1,69 @@
+package nsq
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"maps"
+)
+
+// Dead-letter naming and envelope headers.
+const (
+	deadLetterSuffix    = "-dlq"
+	OriginalTopicHeader = "original_topic" // Header holding the topic a dead-lettered message was consumed from
+	FailureHeader       = "failure"        // Header holding the handler error that dead-lettered the message
+)
+
+// DeadLetterFunc processes a message from a dead-letter topic.
+// It receives the original message body and the topic it originally failed on, so it can republish it after a fix.
+type DeadLetterFunc func(ctx context.Context, msg []byte, originalTopic string) (err error)
+
+// DeadLetterTopic returns the name of the dead-letter topic for the given topic.
+func DeadLetterTopic(topic string) string {
+	return topic + deadLetterSuffix
+}
+
+// publishDeadLetter publishes a failed message to the topic's dead-letter topic,
+// keeping its headers and schema metadata and recording the original topic and the failure.
+func (c *Client) publishDeadLetter(topic string, envelope *Envelope, cause error) (err error) {
+	dlqHeaders := maps.Clone(envelope.Headers)
+	if dlqHeaders == nil {
+		dlqHeaders = make(map[string]string, 2)
+	}
+	dlqHeaders[OriginalTopicHeader] = topic
+	dlqHeaders[FailureHeader] = cause.Error()
+
+	message, err := json.Marshal(&Envelope{
+		Version:       envelopeVersion,
+		SchemaVersion: envelope.SchemaVersion,
+		ContentType:   envelope.ContentType,
+		Headers:       dlqHeaders,
+		Body:          envelope.Body,
+	})
+	if err != nil {
+		return err
+	}
+	if err = c.publish(DeadLetterTopic(topic), message); err != nil {
+		log.Printf("Failed to dead-letter message from topic %s: %v", topic, err)
+		return err
+	}
+	return nil
+}
+
+// ConsumeDLQ consumes the dead-letter topic of the given topic on the given channel,
+// so operators can inspect failed messages and reprocess them after a fix.
+// The handler receives the original body and the topic the message originally failed on;
+// the headers, including the recorded failure, are available through HeadersFromContext.
+// Returning an error from the handler requeues the dead-lettered message.
+// Returns ErrAlreadyRegistered if the channel is already consumed, or an error if the consumer creation or connection fails.
+func (c *Client) ConsumeDLQ(topic, channel string, handler DeadLetterFunc) (err error) {
+	dlqTopic := DeadLetterTopic(topic)
+	return c.subscribe(dlqTopic, channel, func(ctx context.Context, t string) error {
+		body, _ := ctx.Value(t).(string)
+		originalTopic := HeadersFromContext(ctx)[OriginalTopicHeader]
+		if originalTopic == "" {
+			originalTopic = topic
+		}
+		return handler(ctx, []byte(body), originalTopic)
+	}, newConsumerOptions())
+}
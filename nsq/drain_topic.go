@@ -0,0 +1,37 @@
+package nsq
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// drainIdleWindow is how long DrainTopic waits for another message before deciding the topic is empty.
+const drainIdleWindow = time.Second
+
+// DrainTopic consumes the messages pending on the topic and channel, passing each body to handler,
+// until no message arrives within a short idle window. It lets integration tests consume what they
+// published without arbitrary sleeps. Messages are acknowledged when handler succeeds; the first handler
+// error requeues its message and stops the drain. The consumer is stopped before DrainTopic returns.
+// Returns the number of messages handled successfully, and the handler error, the context error,
+// or an error if the consumer cannot be started.
+func (c *Client) DrainTopic(ctx context.Context, topic, channel string, handler func(msg []byte) error) (count int, err error) {
+	defer c.stopReceiver(topic, channel)
+	for {
+		idleCtx, cancel := context.WithTimeout(ctx, drainIdleWindow)
+		received, err := c.Receive(idleCtx, topic, channel)
+		cancel()
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+				return count, nil
+			}
+			return count, err
+		}
+		if err = handler(received.Body); err != nil {
+			received.Nack(-1)
+			return count, err
+		}
+		received.Ack()
+		count++
+	}
+}
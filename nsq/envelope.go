@@ -0,0 +1,108 @@
+package nsq
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/nsqio/go-nsq"
+	"log"
+)
+
+// envelopeVersion is the current version of the envelope wire format.
+const envelopeVersion = 1
+
+// envelopePrefix starts every encoded envelope; messages without it are treated as plain bodies.
+var envelopePrefix = []byte(`{"nsq_envelope":`)
+
+var (
+	// ErrUnsupportedSchemaVersion is recorded as the failure of messages rejected by WithSchemaVersions.
+	ErrUnsupportedSchemaVersion = errors.New("nsq: unsupported message schema version")
+)
+
+type (
+	// Envelope wraps a message body with metadata headers such as content type or correlation ID.
+	// It is encoded as JSON and versioned, so consumers can tell it apart from plain message bodies
+	// and newer formats can be introduced without breaking older consumers.
+	// SchemaVersion and ContentType describe the payload itself, so producers and consumers
+	// can roll out payload format changes independently of the envelope format.
+	Envelope struct {
+		Version       int               `json:"nsq_envelope"`             // The envelope format version, always first on the wire
+		SchemaVersion int               `json:"schema_version,omitempty"` // The version of the payload schema, 0 when unversioned
+		ContentType   string            `json:"content_type,omitempty"`   // The payload media type, e.g. "application/json"
+		Headers       map[string]string `json:"headers,omitempty"`        // Metadata attached to the message
+		Body          []byte            `json:"body"`                     // The actual message content
+	}
+
+	// headersKey is the context key under which consumers expose message headers.
+	headersKey struct{}
+)
+
+// PublishWithHeaders wraps the body in an Envelope carrying the headers and publishes it to the topic.
+// Consumers registered with RegisterConsumer receive the unwrapped body as usual
+// and can read the headers with HeadersFromContext.
+// Returns an error if encoding or the publish operation fails.
+func (c *Client) PublishWithHeaders(ctx context.Context, topic string, headers map[string]string, body []byte) (err error) {
+	message, err := json.Marshal(&Envelope{
+		Version: envelopeVersion,
+		Headers: headers,
+		Body:    body,
+	})
+	if err != nil {
+		return err
+	}
+	return c.Publish(ctx, &NsqEvent{Topic: topic, Message: message})
+}
+
+// PublishEnvelope publishes the payload in envelope to the topic together with its schema version,
+// content type and headers. Version is set by the client and need not be filled in.
+// Consumers receive the payload as the message body; its schema version and content type
+// are available through MessageInfoFromContext and can be checked with WithSchemaVersions.
+// Returns an error if encoding or the publish operation fails.
+func (c *Client) PublishEnvelope(ctx context.Context, topic string, envelope Envelope) (err error) {
+	envelope.Version = envelopeVersion
+	message, err := json.Marshal(&envelope)
+	if err != nil {
+		return err
+	}
+	return c.Publish(ctx, &NsqEvent{Topic: topic, Message: message})
+}
+
+// HeadersFromContext returns the headers of the message being handled,
+// or nil if the message was published without an envelope.
+func HeadersFromContext(ctx context.Context) map[string]string {
+	headers, _ := ctx.Value(headersKey{}).(map[string]string)
+	return headers
+}
+
+// openEnvelope unwraps an enveloped message.
+// Plain messages, and envelopes of a version this client does not know, are returned as the Body
+// of an Envelope with a zero Version and no metadata.
+func openEnvelope(message []byte) (envelope *Envelope) {
+	if !bytes.HasPrefix(message, envelopePrefix) {
+		return &Envelope{Body: message}
+	}
+	envelope = &Envelope{}
+	if err := json.Unmarshal(message, envelope); err != nil || envelope.Version != envelopeVersion {
+		return &Envelope{Body: message}
+	}
+	return envelope
+}
+
+// rejectMessage handles a message the consumer can never process, such as one with an unsupported
+// schema version or content type, without retrying it.
+// It is dead-lettered when the consumer has a dead-letter topic, and dropped otherwise.
+// Returns the disposition of the message, and an error, requeueing the message, only if dead-lettering fails.
+func (c *Client) rejectMessage(topic string, co *consumerOptions, message *nsq.Message, envelope *Envelope, cause error) (disposition string, err error) {
+	if co.deadLetterAfter > 0 {
+		if err = c.publishDeadLetter(topic, envelope, cause); err != nil {
+			message.Requeue(-1)
+			return dispositionRequeued, err
+		}
+		message.Finish()
+		return dispositionDLQ, nil
+	}
+	log.Printf("Dropping message %s on topic %s: %v", message.ID, topic, cause)
+	message.Finish()
+	return dispositionDropped, nil
+}
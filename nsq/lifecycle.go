@@ -0,0 +1,71 @@
+package nsq
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/nsqio/go-nsq"
+)
+
+// Message dispositions recorded by the lifecycle log.
+const (
+	dispositionFinished = "finished" // handled successfully and acknowledged
+	dispositionRequeued = "requeued" // returned to nsqd for another attempt
+	dispositionDLQ      = "dlq"      // moved to the dead-letter topic
+	dispositionDropped  = "dropped"  // acknowledged without success, e.g. after too many attempts
+	dispositionFiltered = "filtered" // acknowledged without running the handler
+)
+
+// messageTrace emits the structured lifecycle log of a single consumed message.
+// A nil trace, used when no logger is configured, logs nothing.
+type messageTrace struct {
+	logger     *slog.Logger
+	attrs      []slog.Attr
+	receivedAt time.Time
+}
+
+// traceMessage starts the lifecycle log of a message received on the topic and channel,
+// logging its receipt at debug level. Returns nil if the client has no logger.
+func (c *Client) traceMessage(topic, channel string, message *nsq.Message) *messageTrace {
+	if c.logger == nil {
+		return nil
+	}
+	t := &messageTrace{
+		logger: c.logger,
+		attrs: []slog.Attr{
+			slog.String("message_id", string(message.ID[:])),
+			slog.String("topic", topic),
+			slog.String("channel", channel),
+			slog.Int("attempts", int(message.Attempts)),
+		},
+		receivedAt: time.Now(),
+	}
+	t.log(slog.LevelDebug, "message received")
+	return t
+}
+
+// handled logs the handler outcome with its duration: processed at debug level, or failed at warn level with the error.
+func (t *messageTrace) handled(duration time.Duration, err error) {
+	if t == nil {
+		return
+	}
+	if err != nil {
+		t.log(slog.LevelWarn, "message failed", slog.Duration("duration", duration), slog.String("error", err.Error()))
+		return
+	}
+	t.log(slog.LevelDebug, "message processed", slog.Duration("duration", duration))
+}
+
+// settled logs the final disposition of the message and the time since it was received, at info level.
+func (t *messageTrace) settled(disposition string) {
+	if t == nil {
+		return
+	}
+	t.log(slog.LevelInfo, "message settled", slog.String("disposition", disposition), slog.Duration("duration", time.Since(t.receivedAt)))
+}
+
+// log writes a lifecycle entry carrying the message attributes.
+func (t *messageTrace) log(level slog.Level, msg string, attrs ...slog.Attr) {
+	t.logger.LogAttrs(context.Background(), level, msg, append(t.attrs[:len(t.attrs):len(t.attrs)], attrs...)...)
+}
@@ -0,0 +1,50 @@
+package nsq
+
+import (
+	"context"
+	"time"
+
+	"github.com/nsqio/go-nsq"
+)
+
+type (
+	// MessageInfo describes the NSQ message being handled by a ConsumerFunc.
+	MessageInfo struct {
+		ID           string        // The nsqd message ID
+		Attempts     uint16        // How many times the message has been delivered, including this one
+		PublishedAt  time.Time     // When nsqd received the message from the producer
+		ReceivedAt   time.Time     // When this consumer received the message
+		QueueLatency time.Duration // How long the message waited in the queue, never negative
+		// SchemaVersion and ContentType describe the payload when it was published with PublishEnvelope.
+		SchemaVersion int
+		ContentType   string
+	}
+
+	// messageInfoKey is the context key under which consumers expose MessageInfo.
+	messageInfoKey struct{}
+)
+
+// newMessageInfo builds the MessageInfo for a message received at receivedAt.
+// Clock skew between nsqd and the consumer can make the computed latency negative; it is clamped to zero.
+func newMessageInfo(message *nsq.Message, receivedAt time.Time) *MessageInfo {
+	publishedAt := time.Unix(0, message.Timestamp)
+	latency := receivedAt.Sub(publishedAt)
+	if latency < 0 {
+		latency = 0
+	}
+	return &MessageInfo{
+		ID:           string(message.ID[:]),
+		Attempts:     message.Attempts,
+		PublishedAt:  publishedAt,
+		ReceivedAt:   receivedAt,
+		QueueLatency: latency,
+	}
+}
+
+// MessageInfoFromContext returns the metadata of the message being handled,
+// such as its receipt time and queue latency for emitting latency metrics.
+// The boolean is false when the context does not come from a consumer handler.
+func MessageInfoFromContext(ctx context.Context) (info *MessageInfo, ok bool) {
+	info, ok = ctx.Value(messageInfoKey{}).(*MessageInfo)
+	return info, ok
+}
@@ -0,0 +1,34 @@
+package nsq
+
+import (
+	"context"
+	"errors"
+	"iter"
+)
+
+// Messages returns an iterator over the message bodies delivered on the topic and channel,
+// for use with range: `for body, err := range client.Messages(ctx, topic, channel)`.
+// It consumes through Receive, acknowledging each message once the loop body has run for it,
+// and a message whose loop body breaks out of the range is acknowledged too.
+// Iteration ends when ctx is cancelled or the loop breaks, and the underlying consumer is then stopped,
+// requeueing any message it still holds. Any other error, such as ErrReceiverStopped,
+// is yielded once with a nil body before iteration ends.
+func (c *Client) Messages(ctx context.Context, topic, channel string) iter.Seq2[[]byte, error] {
+	return func(yield func([]byte, error) bool) {
+		defer c.stopReceiver(topic, channel)
+		for {
+			received, err := c.Receive(ctx, topic, channel)
+			if err != nil {
+				if ctx.Err() == nil || !errors.Is(err, ctx.Err()) {
+					yield(nil, err)
+				}
+				return
+			}
+			more := yield(received.Body, nil)
+			received.Ack()
+			if !more {
+				return
+			}
+		}
+	}
+}
@@ -2,12 +2,38 @@ package nsq
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/RandySteven/common_go/broker"
 	"github.com/nsqio/go-nsq"
+	"iter"
 	"log"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+const (
+	// defaultChannel is the channel name consumers subscribe with.
+	defaultChannel = "channel"
+	// ephemeralSuffix marks a channel that nsqd deletes once its last client disconnects.
+	ephemeralSuffix = "#ephemeral"
+)
+
+var (
+	// ErrAlreadyRegistered is returned when a consumer is registered twice for the same topic and channel.
+	ErrAlreadyRegistered = errors.New("nsq: consumer already registered for topic and channel")
+	// ErrClosed is returned when the client is used after Close.
+	ErrClosed = errors.New("nsq: client is closed")
+	// ErrNotConnected is returned when the client has no producer or configuration,
+	// e.g. a zero-value Client or one whose construction failed.
+	ErrNotConnected = errors.New("nsq: client is not connected")
+	// ErrProducerBusy is returned by publishes that would have to wait for earlier ones, when WithNonBlockingPublish is set.
+	ErrProducerBusy = errors.New("nsq: producer busy")
+)
+
 type (
 	// ConsumerFunc defines the signature for a consumer function that processes messages
 	// from a specific topic. It receives a context and topic name, and returns an error.
@@ -23,10 +49,40 @@ type (
 	NSQ interface {
 		// Publish sends a message to the specified topic
 		Publish(ctx context.Context, event *NsqEvent) (err error)
+		// PublishWithHeaders sends a message wrapped in an envelope carrying the headers
+		PublishWithHeaders(ctx context.Context, topic string, headers map[string]string, body []byte) (err error)
+		// PublishMany sends messages to the specified topic in as few size-limited MultiPublish calls as possible
+		PublishMany(ctx context.Context, topic string, bodies [][]byte) (err error)
+		// PublishEnvelope sends a payload wrapped in an envelope carrying its schema version and content type
+		PublishEnvelope(ctx context.Context, topic string, envelope Envelope) (err error)
+		// Request publishes a message and waits for the reply correlated with it on the reply topic
+		Request(ctx context.Context, topic string, body []byte, replyTopic string, timeout time.Duration) (reply []byte, err error)
+		// Reply publishes the reply to the request being handled
+		Reply(ctx context.Context, body []byte) (err error)
 		// Consume retrieves a message from the specified topic
 		Consume(ctx context.Context, topic string) (value string, err error)
+		// ConsumeWait blocks until a message arrives for the specified topic or the timeout elapses
+		ConsumeWait(ctx context.Context, topic string, timeout time.Duration) (value string, err error)
 		// RegisterConsumer sets up a consumer function for a specific topic
-		RegisterConsumer(topic string, cf ConsumerFunc) (err error)
+		RegisterConsumer(topic string, cf ConsumerFunc, opts ...ConsumerOption) (err error)
+		// Receive waits for the next message on the topic and channel, leaving its acknowledgement to the caller
+		Receive(ctx context.Context, topic, channel string) (received *ReceivedMessage, err error)
+		// Messages returns an iterator over the message bodies delivered on the topic and channel
+		Messages(ctx context.Context, topic, channel string) iter.Seq2[[]byte, error]
+		// DrainTopic consumes the messages pending on the topic and channel until none arrive for a short while
+		DrainTopic(ctx context.Context, topic, channel string, handler func(msg []byte) error) (count int, err error)
+		// Drain stops pulling new messages and waits for in-flight handlers before stopping consumers
+		Drain(ctx context.Context) (err error)
+		// Stop immediately stops all registered consumers without waiting for in-flight handlers
+		Stop()
+		// Ping checks that nsqd is reachable for publishing
+		Ping(ctx context.Context) (err error)
+		// Close stops all consumers and the producers, releasing their connections
+		Close() (err error)
+		// Subscribe runs handler for every message delivered to the channel named group on the specified topic
+		Subscribe(ctx context.Context, topic, group string, handler broker.Handler) (err error)
+		// ConsumeDLQ consumes the dead-letter topic of the specified topic
+		ConsumeDLQ(topic, channel string, handler DeadLetterFunc) (err error)
 	}
 
 	// Client represents an NSQ client that handles publishing and consuming messages.
@@ -34,6 +90,32 @@ type (
 		Pub     *nsq.Producer // NSQ producer for publishing messages
 		Config  *nsq.Config   // NSQ configuration settings
 		Lookupd string        // NSQ lookupd address for service discovery
+
+		mu          sync.Mutex             // guards consumers, registered, received, waiters, receivers, controllers and replies
+		consumers   []*nsq.Consumer        // consumers created by RegisterConsumer
+		registered  map[string]struct{}    // registered topic/channel pairs
+		received    map[string]chan string // message bodies received per topic while ConsumeWait is waiting on it
+		waiters     map[string]int         // number of ConsumeWait calls waiting per topic
+		receivers   map[string]*receiver   // consumers started by Receive per topic/channel pair
+		controllers []inFlightController   // MaxInFlight tuners, ramps and circuit breakers, halted when consumers are taken
+		inFlight    atomic.Int64           // number of handler executions in progress
+		handlers    chan struct{}          // slots bounding concurrent handlers across consumers, nil when uncapped
+
+		replyMu sync.Mutex                  // serializes subscribing to reply topics
+		replies map[string]*replyDispatcher // reply topic consumers started by Request, guarded by mu
+
+		publishQueue chan *publishRequest // serializes publishes when ordered publishing is enabled
+		pool         *producerPool        // spreads publishes across nodes when several are configured
+		closed       atomic.Bool          // set by Close
+		closing      chan struct{}        // closed by Close to stop the publish queue
+		publishSlots chan struct{}        // bounds pending publishes when publishing is non-blocking, nil otherwise
+
+		multiPublishBytes int // largest MPUB body PublishMany sends in one call, unlimited when 0 or less
+		multiPublishCount int // most messages PublishMany sends in one call, unlimited when 0 or less
+
+		logger     *slog.Logger       // receives the per-message lifecycle log, nil to disable it
+		base       context.Context    // parent of every handler context, cancelled by Close
+		cancelBase context.CancelFunc // cancels base
 	}
 
 	// NSQConfig holds configuration parameters for connecting to NSQ.
@@ -41,60 +123,393 @@ type (
 		Host     string // NSQ host address
 		DTCPPort string // TCP port for NSQ daemon
 		HTTPPort string // HTTP port for NSQ lookupd
+
+		// Nodes lists nsqd TCP addresses (host:port) to spread publishes across.
+		// When set, it replaces Host and DTCPPort for publishing and unhealthy nodes are skipped.
+		Nodes []string
+
+		// LookupdAddr is the nsqlookupd HTTP address (host:port) consumers discover nsqd through.
+		// When set, it replaces Host and HTTPPort for lookups, for nsqlookupd running on another host.
+		LookupdAddr string
+
+		// DialTimeout bounds how long connecting to nsqd may take, overriding nsq's 1s default.
+		// When set, NewNSQClient also pings nsqd so an unreachable broker fails startup right away.
+		DialTimeout time.Duration
 	}
 )
 
 // RegisterConsumer creates and registers a consumer for the specified topic.
 // It sets up a handler that processes incoming messages using the provided ConsumerFunc.
 // The consumer will automatically connect to NSQ lookupd and start processing messages.
-// Returns an error if the consumer creation or connection fails.
-func (c *Client) RegisterConsumer(topic string, cf ConsumerFunc) (err error) {
-	consumer, err := nsq.NewConsumer(topic, "channel", c.Config)
+// Optional behaviour such as dropping poison messages is configured through opts.
+// Returns ErrNotConnected on a client without configuration, ErrAlreadyRegistered if a consumer already exists for the topic and channel,
+// or an error if the consumer creation or connection fails.
+func (c *Client) RegisterConsumer(topic string, cf ConsumerFunc, opts ...ConsumerOption) (err error) {
+	return c.subscribe(topic, defaultChannel, cf, newConsumerOptions(opts...))
+}
+
+// Subscribe registers handler as a consumer of the topic on the channel named group,
+// passing it the message body instead of the topic name.
+// Each group receives its own copy of every message, and consumers sharing a group split the messages between them.
+// The context only bounds registration; the consumer runs until Drain or Stop.
+// Returns ErrAlreadyRegistered if a consumer already exists for the topic and group.
+func (c *Client) Subscribe(ctx context.Context, topic, group string, handler broker.Handler) (err error) {
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	return c.subscribe(topic, group, func(ctx context.Context, t string) error {
+		body, _ := ctx.Value(t).(string)
+		return handler(ctx, []byte(body))
+	}, newConsumerOptions())
+}
+
+// subscribe creates a consumer for the topic and channel that runs cf for every message,
+// connects it through lookupd and tracks it on the client.
+func (c *Client) subscribe(topic, channel string, cf ConsumerFunc, co *consumerOptions) (err error) {
+	if c.Config == nil {
+		return ErrNotConnected
+	}
+	if co.ephemeral && !strings.HasSuffix(channel, ephemeralSuffix) {
+		channel += ephemeralSuffix
+	}
+	if err = c.register(topic, channel); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			c.unregister(topic, channel)
+		}
+	}()
+
+	config, err := co.consumerConfig(c.Config)
+	if err != nil {
+		return err
+	}
+	consumer, err := nsq.NewConsumer(topic, channel, config)
 	if err != nil {
 		return err
 	}
 
-	consumer.AddHandler(nsq.HandlerFunc(func(message *nsq.Message) error {
-		body := string(message.Body)
-		ctx := context.WithValue(context.Background(), topic, body)
+	var changer maxInFlightChanger = consumer
+	var controllers []inFlightController
+	var breaker *circuitBreaker
+	if co.breaker != nil {
+		breaker = newCircuitBreaker(*co.breaker, config.MaxInFlight, consumer)
+		changer = breaker
+		controllers = append(controllers, breaker)
+	}
+	var tuner *inFlightTuner
+	if co.adaptive != nil {
+		tuner = newInFlightTuner(*co.adaptive, changer)
+		tuner.start()
+		controllers = append(controllers, tuner)
+	}
+	var ramp *inFlightRamp
+	if co.ramp != nil && tuner == nil {
+		ramp = newInFlightRamp(*co.ramp, config.MaxInFlight, changer)
+		controllers = append(controllers, ramp)
+	}
+
+	handle := func(message *nsq.Message) error {
+		receivedAt := time.Now()
+		trace := c.traceMessage(topic, channel, message)
+		envelope := openEnvelope(message.Body)
+		payload, headers := envelope.Body, envelope.Headers
+		if !co.acceptsSchemaVersion(envelope.SchemaVersion) {
+			disposition, err := c.rejectMessage(topic, co, message, envelope, fmt.Errorf("%w: %d", ErrUnsupportedSchemaVersion, envelope.SchemaVersion))
+			trace.settled(disposition)
+			return err
+		}
+		if co.filter != nil && !co.filter(payload) {
+			message.Finish()
+			trace.settled(dispositionFiltered)
+			return nil
+		}
+
+		c.inFlight.Add(1)
+		defer c.inFlight.Add(-1)
+		if c.handlers != nil {
+			c.handlers <- struct{}{}
+			defer func() { <-c.handlers }()
+		}
+
+		body := string(payload)
+		c.offer(topic, body)
+		ctx := context.WithValue(c.baseContext(), topic, body)
+		info := newMessageInfo(message, receivedAt)
+		info.SchemaVersion, info.ContentType = envelope.SchemaVersion, envelope.ContentType
+		ctx = context.WithValue(ctx, messageInfoKey{}, info)
+		if headers != nil {
+			ctx = context.WithValue(ctx, headersKey{}, headers)
+		}
 		ctx, cancel := context.WithTimeout(ctx, time.Second*30)
 		defer cancel()
 
-		if err := func() error {
-			cf(ctx, topic)
-			return nil
-		}(); err != nil {
+		started := time.Now()
+		err := cf(ctx, topic)
+		if errors.Is(err, ErrUnsupportedContentType) {
+			trace.handled(time.Since(started), err)
+			disposition, err := c.rejectMessage(topic, co, message, envelope, err)
+			trace.settled(disposition)
+			return err
+		}
+		if tuner != nil {
+			tuner.observe(time.Since(started))
+		}
+		if breaker != nil {
+			breaker.record(err)
+		}
+		trace.handled(time.Since(started), err)
+		if err != nil {
+			if co.onError != nil {
+				co.onError(ctx, payload, message.Attempts, err)
+			}
+			if co.deadLetterAfter > 0 && int(message.Attempts) >= co.deadLetterAfter {
+				if dlqErr := c.publishDeadLetter(topic, envelope, err); dlqErr == nil {
+					message.Finish()
+					trace.settled(dispositionDLQ)
+					return nil
+				}
+			}
+			if co.dropAfterAttempts > 0 && int(message.Attempts) >= co.dropAfterAttempts {
+				log.Printf("Dropping message %s on topic %s after %d attempts: %v", message.ID, topic, message.Attempts, err)
+				message.Finish()
+				trace.settled(dispositionDropped)
+				return nil
+			}
 			log.Println("Error in handlerFunc:", err)
 			message.Requeue(-1)
+			trace.settled(dispositionRequeued)
 			return err
 		}
 
+		trace.settled(dispositionFinished)
 		return nil
-	}))
+	}
+	if co.orderingKey != nil {
+		consumer.AddHandler(c.orderedHandler(co.orderingKey, handle))
+	} else {
+		consumer.AddHandler(nsq.HandlerFunc(handle))
+	}
 
+	if ramp != nil {
+		ramp.start()
+	}
 	if err = consumer.ConnectToNSQLookupd(c.Lookupd); err != nil {
+		for _, controller := range controllers {
+			controller.halt()
+		}
 		return err
 	}
+
+	if co.onConnection != nil {
+		go watchConnections(topic, channel, consumer, connectionPollInterval, consumer.StopChan, co.onConnection)
+	}
+
+	c.mu.Lock()
+	c.consumers = append(c.consumers, consumer)
+	c.controllers = append(c.controllers, controllers...)
+	c.mu.Unlock()
+	return nil
+}
+
+// Drain gracefully shuts down all registered consumers.
+// It sets MaxInFlight to 0 so no new messages are pulled, then waits for in-flight handlers
+// to finish until the context is done, and finally stops the consumers and waits, within the same context,
+// for them to close their connections, so callers can safely release what the consumers depend on next.
+// Messages whose handlers have not completed when the context expires are left unacknowledged
+// and requeued by nsqd once the consumer connections close.
+// Returns the context error if the grace window elapsed before all handlers finished and the consumers stopped.
+func (c *Client) Drain(ctx context.Context) (err error) {
+	consumers := c.takeConsumers()
+	for _, consumer := range consumers {
+		consumer.ChangeMaxInFlight(0)
+	}
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for c.inFlight.Load() > 0 && err == nil {
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	for _, consumer := range consumers {
+		consumer.Stop()
+	}
+	for _, consumer := range consumers {
+		if err != nil {
+			break
+		}
+		select {
+		case <-consumer.StopChan:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+	return err
+}
+
+// Stop immediately stops all registered consumers.
+// Unlike Drain it does not wait for in-flight handlers; their messages are requeued by nsqd.
+func (c *Client) Stop() {
+	for _, consumer := range c.takeConsumers() {
+		consumer.Stop()
+	}
+}
+
+// Close cancels the context of every running handler, stops all registered consumers immediately, like Stop,
+// and then stops the producers, closing their connections to nsqd. Publishing or pinging afterwards returns ErrClosed.
+// Calling Close more than once is a no-op.
+func (c *Client) Close() (err error) {
+	if !c.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+	if c.cancelBase != nil {
+		c.cancelBase()
+	}
+	c.Stop()
+	if c.closing != nil {
+		close(c.closing)
+	}
+	if c.pool != nil {
+		c.pool.Stop()
+	} else if c.Pub != nil {
+		c.Pub.Stop()
+	}
 	return nil
 }
 
+// baseContext returns the context handler contexts are derived from.
+func (c *Client) baseContext() context.Context {
+	if c.base == nil {
+		return context.Background()
+	}
+	return c.base
+}
+
+// register records the topic/channel pair, failing if it is already registered.
+func (c *Client) register(topic, channel string) (err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.registered == nil {
+		c.registered = make(map[string]struct{})
+	}
+	key := topic + "/" + channel
+	if _, ok := c.registered[key]; ok {
+		return fmt.Errorf("%w: %s", ErrAlreadyRegistered, key)
+	}
+	c.registered[key] = struct{}{}
+	return nil
+}
+
+// unregister forgets the topic/channel pair so it can be registered again.
+func (c *Client) unregister(topic, channel string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.registered, topic+"/"+channel)
+}
+
+// takeConsumers removes and returns the consumers registered on the client, halting their MaxInFlight controllers.
+// Their topic/channel pairs are released so they can be registered again.
+func (c *Client) takeConsumers() []*nsq.Consumer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	consumers := c.consumers
+	c.consumers = nil
+	c.registered = nil
+	for _, r := range c.receivers {
+		close(r.stop)
+	}
+	c.receivers = nil
+	for _, controller := range c.controllers {
+		controller.halt()
+	}
+	c.controllers = nil
+	c.replies = nil
+	return consumers
+}
+
 var _ NSQ = &Client{}
 
 // NewNSQClient creates a new NSQ client instance with the provided configuration.
 // It initializes both the producer and lookupd connection settings.
+// Optional behaviour such as ordered publishing is configured through opts.
+// When config.DialTimeout is set, nsqd must be reachable within that timeout.
 // Returns an NSQ interface implementation or an error if initialization fails.
-func NewNSQClient(config *NSQConfig) (result NSQ, err error) {
+func NewNSQClient(config *NSQConfig, opts ...Option) (result NSQ, err error) {
+	o := newOptions(opts...)
+
 	nsqConfig := nsq.NewConfig()
+	if config.DialTimeout > 0 {
+		nsqConfig.DialTimeout = config.DialTimeout
+	}
+	if o.outputBuffer != nil {
+		if err = o.outputBuffer.apply(nsqConfig); err != nil {
+			return nil, err
+		}
+	}
+	for _, setting := range o.settings {
+		if err = nsqConfig.Set(setting.name, setting.value); err != nil {
+			return nil, fmt.Errorf("nsq: invalid config option %s: %w", setting.name, err)
+		}
+	}
+	lookupd := config.LookupdAddr
+	if lookupd == "" {
+		lookupd = fmt.Sprintf("%s:%s", config.Host, config.HTTPPort)
+	}
 
-	addr := fmt.Sprintf("%s:%s", config.Host, config.DTCPPort)
-	producer, err := nsq.NewProducer(addr, nsqConfig)
-	if err != nil {
-		return nil, err
+	addrs := config.Nodes
+	if len(addrs) == 0 {
+		addrs = []string{fmt.Sprintf("%s:%s", config.Host, config.DTCPPort)}
+	}
+	producers := make([]*nsq.Producer, 0, len(addrs))
+	for _, addr := range addrs {
+		producer, err := nsq.NewProducer(addr, nsqConfig)
+		if err != nil {
+			return nil, err
+		}
+		producers = append(producers, producer)
 	}
 
-	return &Client{
-		Pub:     producer,
+	client := &Client{
+		Pub:     producers[0],
 		Config:  nsqConfig,
-		Lookupd: fmt.Sprintf("%s:%s", config.Host, config.HTTPPort),
-	}, nil
+		Lookupd: lookupd,
+		closing: make(chan struct{}),
+		logger:  o.logger,
+
+		multiPublishBytes: o.multiPublish[0],
+		multiPublishCount: o.multiPublish[1],
+	}
+	client.base, client.cancelBase = context.WithCancel(o.baseContext)
+	if len(producers) > 1 {
+		pooled := make([]producer, len(producers))
+		for i, producer := range producers {
+			pooled[i] = producer
+		}
+		client.pool = newProducerPool(pooled)
+		client.pool.partitionKey = o.partitionKey
+	}
+	if config.DialTimeout > 0 {
+		if err = client.Ping(context.Background()); err != nil {
+			for _, producer := range producers {
+				producer.Stop()
+			}
+			return nil, fmt.Errorf("nsq: connect to nsqd: %w", err)
+		}
+	}
+	if o.maxHandlers > 0 {
+		client.handlers = make(chan struct{}, o.maxHandlers)
+	}
+	if o.maxPendingPublishes > 0 {
+		client.publishSlots = make(chan struct{}, o.maxPendingPublishes)
+	}
+	if o.orderedPublish {
+		client.publishQueue = make(chan *publishRequest)
+		go client.runPublishQueue()
+	}
+	return client, nil
 }
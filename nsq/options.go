@@ -0,0 +1,344 @@
+package nsq
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nsqio/go-nsq"
+)
+
+type (
+	// Option configures optional behaviour of a Client.
+	Option func(*options)
+
+	// options holds the optional settings of a Client.
+	options struct {
+		orderedPublish      bool
+		partitionKey        PartitionKeyFunc
+		maxHandlers         int
+		outputBuffer        *outputBuffer
+		baseContext         context.Context
+		logger              *slog.Logger
+		maxPendingPublishes int
+		multiPublish        [2]int // byte and message count limits of a single MultiPublish in PublishMany
+		settings            []configSetting
+	}
+
+	// outputBuffer holds the nsqd output buffer settings requested with WithOutputBuffer.
+	outputBuffer struct {
+		size    int64
+		timeout time.Duration
+	}
+
+	// PartitionKeyFunc extracts the key that decides which nsqd node a message is published to,
+	// e.g. an order ID parsed from the body.
+	PartitionKeyFunc func(topic string, body []byte) (key string)
+
+	// ConsumerOption configures optional behaviour of a consumer created by RegisterConsumer.
+	ConsumerOption func(*consumerOptions)
+
+	// consumerOptions holds the optional settings of a single consumer.
+	consumerOptions struct {
+		dropAfterAttempts int
+		filter            func(msg []byte) bool
+		adaptive          *AdaptiveMaxInFlight
+		ramp              *maxInFlightRamp
+		breaker           *CircuitBreaker
+		onConnection      ConnectionCallback
+		ephemeral         bool
+		deadLetterAfter   int
+		schemaVersions    *[2]int // accepted schema version range, inclusive; nil accepts every version
+		orderingKey       func(msg []byte) string
+		onError           ErrorCallback
+		settings          []configSetting
+	}
+
+	// ErrorCallback is called when a consumer's handler fails, with the handler's context,
+	// the message body, the delivery attempt and the handler error.
+	ErrorCallback func(ctx context.Context, msg []byte, attempts uint16, err error)
+
+	// configSetting is an nsq.Config option applied to a single consumer's configuration.
+	configSetting struct {
+		name  string
+		value interface{}
+	}
+)
+
+// WithOrderedPublish serializes all publishes through a single worker goroutine,
+// so messages are sent to nsqd in the order Publish was called, even across goroutines.
+// This trades throughput for ordering: only one publish is in flight at a time.
+func WithOrderedPublish() Option {
+	return func(o *options) {
+		o.orderedPublish = true
+	}
+}
+
+// WithNonBlockingPublish makes Publish, PublishMany and the publishers built on them fail fast with ErrProducerBusy
+// instead of waiting when maxPending publishes are already in progress, e.g. while nsqd is slow to acknowledge
+// a burst, so callers can shed load. A publish that gets a slot still waits for nsqd's acknowledgement.
+// A PublishMany call takes one slot per MultiPublish. Zero or less leaves publishing blocking.
+func WithNonBlockingPublish(maxPending int) Option {
+	return func(o *options) {
+		o.maxPendingPublishes = maxPending
+	}
+}
+
+// WithPartitioning publishes each message to an nsqd node chosen by hashing its partition key,
+// so related messages land on the same node and keep a rough ordering. Without a key function the topic is hashed.
+// If the chosen node is unhealthy the message fails over to the next node like any pooled publish.
+// The nodes are those listed in NSQConfig.Nodes; with a single node this option has no effect.
+func WithPartitioning(key PartitionKeyFunc) Option {
+	return func(o *options) {
+		if key == nil {
+			key = func(topic string, _ []byte) string { return topic }
+		}
+		o.partitionKey = key
+	}
+}
+
+// WithMaxConcurrentHandlers caps the number of handler executions running at once across
+// every consumer registered on the client, regardless of each consumer's MaxInFlight.
+// Messages beyond the cap wait for a free slot before their handler starts,
+// which keeps a process consuming many topics from oversubscribing a shared resource such as a database.
+// Zero leaves concurrency uncapped.
+func WithMaxConcurrentHandlers(limit int) Option {
+	return func(o *options) {
+		o.maxHandlers = limit
+	}
+}
+
+// WithOutputBuffer sets how nsqd buffers writes to the client's connections: up to size bytes
+// are collected and flushed at the latest after timeout. A timeout of 0 flushes every write immediately,
+// trading nsqd CPU and throughput for the lowest delivery latency; the defaults are 16KB and 250ms.
+// Very low timeouts (under 25ms) noticeably raise nsqd CPU usage with many clients connected.
+// NewNSQClient returns an error if either value is negative.
+func WithOutputBuffer(size int64, timeout time.Duration) Option {
+	return func(o *options) {
+		o.outputBuffer = &outputBuffer{size: size, timeout: timeout}
+	}
+}
+
+// apply validates the output buffer settings and sets them on config.
+func (b *outputBuffer) apply(config *nsq.Config) (err error) {
+	if b.size < 0 || b.timeout < 0 {
+		return fmt.Errorf("nsq: output buffer size %d and timeout %v must not be negative", b.size, b.timeout)
+	}
+	config.OutputBufferSize = b.size
+	config.OutputBufferTimeout = b.timeout
+	return nil
+}
+
+// WithBaseContext derives every handler context from ctx instead of context.Background(),
+// so cancelling ctx, for example on a shutdown signal, cancels in-flight handlers.
+// Handler contexts are also cancelled when the client is closed, with or without this option.
+// Values stored in ctx are visible to handlers.
+func WithBaseContext(ctx context.Context) Option {
+	return func(o *options) {
+		if ctx != nil {
+			o.baseContext = ctx
+		}
+	}
+}
+
+// WithMultiPublishLimit caps each MultiPublish call made by PublishMany at maxBytes of MPUB body
+// and maxCount messages; larger batches are split into several calls.
+// maxBytes should not exceed nsqd's --max-body-size, 5MB by default, which is also the default limit.
+// A maxCount of 0 or less leaves the number of messages per call unlimited.
+func WithMultiPublishLimit(maxBytes, maxCount int) Option {
+	return func(o *options) {
+		o.multiPublish = [2]int{maxBytes, maxCount}
+	}
+}
+
+// WithLogger writes a structured lifecycle log of every message handled by consumers registered
+// with RegisterConsumer or Subscribe: its receipt and successful processing at debug level,
+// handler failures at warn level, and its final disposition (finished, requeued, dlq, dropped or filtered)
+// at info level. Each entry carries the message ID, topic, channel and attempt, and outcomes carry durations.
+// Without a logger no lifecycle log is written.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// newOptions applies the given Option values on top of the defaults.
+func newOptions(opts ...Option) *options {
+	o := &options{
+		baseContext:  context.Background(),
+		multiPublish: [2]int{defaultMultiPublishBytes, 0},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithDropAfterAttempts drops a message once its handler has failed on the given attempt.
+// The message is finished and a warning is logged instead of requeueing it again,
+// a lighter alternative to a dead-letter topic for poison messages. Zero disables dropping.
+func WithDropAfterAttempts(attempts int) ConsumerOption {
+	return func(o *consumerOptions) {
+		o.dropAfterAttempts = attempts
+	}
+}
+
+// WithDeadLetter moves a message to the topic's dead-letter topic, named "<topic>-dlq",
+// once its handler has failed on the given attempt. The message is published in an Envelope
+// recording the original topic and the failure, then finished. If publishing to the dead-letter topic
+// fails the message is requeued as usual. Zero disables dead-lettering.
+func WithDeadLetter(attempts int) ConsumerOption {
+	return func(o *consumerOptions) {
+		o.deadLetterAfter = attempts
+	}
+}
+
+// WithSchemaVersions only passes messages whose payload schema version lies between min and max, inclusive,
+// to the ConsumerFunc. Messages published without a schema version count as version 0.
+// Rejected messages are moved to the dead-letter topic when WithDeadLetter is also set,
+// and finished with a logged warning otherwise, so a consumer never misreads a format it does not understand.
+func WithSchemaVersions(min, max int) ConsumerOption {
+	return func(o *consumerOptions) {
+		o.schemaVersions = &[2]int{min, max}
+	}
+}
+
+// acceptsSchemaVersion reports whether the consumer handles the given payload schema version.
+func (o *consumerOptions) acceptsSchemaVersion(version int) bool {
+	return o.schemaVersions == nil || (version >= o.schemaVersions[0] && version <= o.schemaVersions[1])
+}
+
+// WithOrderingKey processes messages that share a key, as extracted from the message body by key,
+// strictly one at a time in the order they were delivered, while messages with different keys run concurrently.
+// Concurrency across keys is bounded by the consumer's MaxInFlight, so raise it in the client Config
+// or with WithAdaptiveMaxInFlight; with the default of 1 every message is processed in turn.
+// Ordering holds for deliveries to this consumer: a requeued message is redelivered after later ones.
+func WithOrderingKey(key func(msg []byte) string) ConsumerOption {
+	return func(o *consumerOptions) {
+		o.orderingKey = key
+	}
+}
+
+// WithOnError calls onError every time the consumer's handler fails, before the message is requeued,
+// dead-lettered or dropped, so callers can emit metrics or alerts instead of parsing logs.
+// The callback runs on the handler goroutine and should return quickly.
+func WithOnError(onError ErrorCallback) ConsumerOption {
+	return func(o *consumerOptions) {
+		o.onError = onError
+	}
+}
+
+// WithFilter only passes messages for which filter returns true to the ConsumerFunc.
+// Messages failing the predicate are finished (acknowledged) without invoking the handler,
+// which avoids wasted work when subscribing to a broad topic.
+func WithFilter(filter func(msg []byte) bool) ConsumerOption {
+	return func(o *consumerOptions) {
+		o.filter = filter
+	}
+}
+
+// WithAdaptiveMaxInFlight lets the consumer tune its MaxInFlight between cfg.Min and cfg.Max
+// using ChangeMaxInFlight: it backs off when handlers slow down and recovers once they speed up,
+// so slow handlers do not accumulate a pile of delivered but unprocessed messages.
+func WithAdaptiveMaxInFlight(cfg AdaptiveMaxInFlight) ConsumerOption {
+	return func(o *consumerOptions) {
+		o.adaptive = &cfg
+	}
+}
+
+// WithMaxInFlightRamp starts the consumer at a MaxInFlight of from and raises it stepwise
+// to the configured MaxInFlight over duration using ChangeMaxInFlight, so a cold start does not
+// overwhelm downstream dependencies that are still warming up. The ramp takes at most 20 steps.
+// It has no effect together with WithAdaptiveMaxInFlight, which manages MaxInFlight itself.
+func WithMaxInFlightRamp(from int, duration time.Duration) ConsumerOption {
+	return func(o *consumerOptions) {
+		o.ramp = &maxInFlightRamp{from: from, duration: duration}
+	}
+}
+
+// WithCircuitBreaker pauses the consumer after cfg.Failures consecutive handler failures by setting
+// its MaxInFlight to 0, so messages are not burnt through retries against a dependency that is down.
+// After cfg.Cooldown a single message is let through as a probe: if its handler succeeds the consumer resumes
+// at its full MaxInFlight, and if it fails consumption pauses for another cooldown.
+// Messages already in flight when the breaker opens are still handled and requeued as usual.
+func WithCircuitBreaker(cfg CircuitBreaker) ConsumerOption {
+	return func(o *consumerOptions) {
+		o.breaker = &cfg
+	}
+}
+
+// WithConnectionCallback calls cb whenever the number of nsqd connections the consumer holds changes,
+// e.g. as nodes join or leave the topology or a flapping node drops its connection, so operators can observe it.
+// go-nsq handles rediscovery itself and does not expose connection events, so the count is checked every second
+// and short-lived changes between checks are not reported. Stopping the consumer reports the loss of its connections.
+// The callback runs on a background goroutine.
+func WithConnectionCallback(cb ConnectionCallback) ConsumerOption {
+	return func(o *consumerOptions) {
+		o.onConnection = cb
+	}
+}
+
+// WithEphemeralChannel subscribes on an ephemeral channel by appending "#ephemeral" to the channel name,
+// so nsqd deletes the channel and its queued messages once the last consumer disconnects instead of
+// keeping an orphan channel after a one-off tool or debug tap exits. Ephemeral channels never spill to disk,
+// so messages beyond nsqd's in-memory queue are dropped. The suffixed name is the one registered on the client.
+func WithEphemeralChannel() ConsumerOption {
+	return func(o *consumerOptions) {
+		o.ephemeral = true
+	}
+}
+
+// WithSampleRate asks nsqd to deliver only the given percentage of the topic's messages
+// to this consumer, which is enough for statistical analysis of high-volume topics.
+// The percentage must be between 0 and 99, where 0 disables sampling; RegisterConsumer
+// returns an error for values outside that range.
+func WithSampleRate(percent int) ConsumerOption {
+	return func(o *consumerOptions) {
+		o.settings = append(o.settings, configSetting{name: "sample_rate", value: percent})
+	}
+}
+
+// WithHeartbeatInterval sets how often nsqd and the consumer exchange heartbeats.
+// It must be less than the configured ReadTimeout, otherwise nsqd's heartbeats cannot arrive
+// before the read times out and the connection is dropped; RegisterConsumer returns an error in that case.
+func WithHeartbeatInterval(interval time.Duration) ConsumerOption {
+	return func(o *consumerOptions) {
+		o.settings = append(o.settings, configSetting{name: "heartbeat_interval", value: interval})
+	}
+}
+
+// WithMsgTimeout sets how long nsqd waits for the consumer to finish or touch a message
+// before requeueing it, for handlers that take longer than nsqd's default.
+// The timeout cannot exceed nsqd's --max-msg-timeout.
+func WithMsgTimeout(timeout time.Duration) ConsumerOption {
+	return func(o *consumerOptions) {
+		o.settings = append(o.settings, configSetting{name: "msg_timeout", value: timeout})
+	}
+}
+
+// consumerConfig returns a copy of base with the consumer's settings applied,
+// so per-consumer options never leak into the Client's shared configuration.
+// Returns an error if a setting is invalid.
+func (o *consumerOptions) consumerConfig(base *nsq.Config) (config *nsq.Config, err error) {
+	copied := *base
+	config = &copied
+	for _, setting := range o.settings {
+		if err = config.Set(setting.name, setting.value); err != nil {
+			return nil, fmt.Errorf("nsq: invalid consumer option %s: %w", setting.name, err)
+		}
+	}
+	if config.HeartbeatInterval >= config.ReadTimeout {
+		return nil, fmt.Errorf("nsq: heartbeat interval %v must be less than read timeout %v", config.HeartbeatInterval, config.ReadTimeout)
+	}
+	return config, nil
+}
+
+// newConsumerOptions applies the given ConsumerOption values on top of the defaults.
+func newConsumerOptions(opts ...ConsumerOption) *consumerOptions {
+	o := &consumerOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
@@ -0,0 +1,66 @@
+package nsq
+
+import (
+	"sync"
+
+	"github.com/nsqio/go-nsq"
+)
+
+// keyedQueue runs tasks one at a time per key, in the order they were added,
+// with a worker goroutine for each key that has pending tasks.
+type keyedQueue struct {
+	mu     sync.Mutex
+	queues map[string][]func() // pending tasks per key; a key is present while its worker runs
+}
+
+// add queues the task behind earlier tasks with the same key, starting a worker for the key if none is running.
+func (q *keyedQueue) add(key string, task func()) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if pending, ok := q.queues[key]; ok {
+		q.queues[key] = append(pending, task)
+		return
+	}
+	q.queues[key] = nil
+	go q.run(key, task)
+}
+
+// run executes task and then the key's queued tasks until none are left.
+func (q *keyedQueue) run(key string, task func()) {
+	for task != nil {
+		task()
+
+		q.mu.Lock()
+		if pending := q.queues[key]; len(pending) > 0 {
+			task = pending[0]
+			q.queues[key] = pending[1:]
+		} else {
+			delete(q.queues, key)
+			task = nil
+		}
+		q.mu.Unlock()
+	}
+}
+
+// orderedHandler hands each message to handle on a per-key queue, so messages with the same key
+// are handled in delivery order while the nsq handler goroutine moves on to the next message.
+// Messages are responded to once handle returns, and count as in flight while they wait, so Drain waits for them.
+func (c *Client) orderedHandler(key func(msg []byte) string, handle func(message *nsq.Message) error) nsq.Handler {
+	queue := &keyedQueue{queues: make(map[string][]func())}
+	return nsq.HandlerFunc(func(message *nsq.Message) error {
+		message.DisableAutoResponse()
+		c.inFlight.Add(1)
+		queue.add(key(openEnvelope(message.Body).Body), func() {
+			defer c.inFlight.Add(-1)
+			err := handle(message)
+			if !message.HasResponded() {
+				if err != nil {
+					message.Requeue(-1)
+				} else {
+					message.Finish()
+				}
+			}
+		})
+		return nil
+	})
+}
@@ -0,0 +1,127 @@
+package nsq
+
+import (
+	"hash/fnv"
+	"sync/atomic"
+	"time"
+)
+
+// unhealthyCooldown is how long a producer is skipped after a failed publish.
+const unhealthyCooldown = 5 * time.Second
+
+var _ producer = &producerPool{}
+
+type (
+	// producer is the subset of *nsq.Producer used for publishing,
+	// so several nsqd nodes can be combined behind a single producer.
+	producer interface {
+		Publish(topic string, body []byte) error
+		MultiPublish(topic string, body [][]byte) error
+		Ping() error
+		Stop()
+	}
+
+	// producerPool spreads publishes across several nsqd nodes round-robin,
+	// skipping nodes whose last publish failed until their cooldown has elapsed.
+	producerPool struct {
+		producers      []producer
+		unhealthyUntil []atomic.Int64 // unix nanoseconds until which each producer is skipped
+		next           atomic.Uint64
+		partitionKey   PartitionKeyFunc // picks the starting producer by key hash instead of round-robin when set
+	}
+)
+
+// newProducerPool creates a pool over the given producers.
+func newProducerPool(producers []producer) *producerPool {
+	return &producerPool{
+		producers:      producers,
+		unhealthyUntil: make([]atomic.Int64, len(producers)),
+	}
+}
+
+// Publish sends the message through the next healthy producer, failing over to the
+// remaining nodes on error. Nodes in their cooldown are only tried once every healthy node has failed.
+// With a partition key the first producer tried is chosen by hashing the key instead of round-robin.
+// Returns the last publish error if no node accepted the message.
+func (p *producerPool) Publish(topic string, body []byte) (err error) {
+	var start int
+	if p.partitionKey != nil {
+		start = p.partition(p.partitionKey(topic, body))
+	} else {
+		start = int(p.next.Add(1)-1) % len(p.producers)
+	}
+	return p.publishFrom(start, func(prod producer) error {
+		return prod.Publish(topic, body)
+	})
+}
+
+// MultiPublish sends the messages in a single batch through the next healthy producer, failing over like Publish.
+// With a partition key the producer is chosen by the key of the first message.
+// Returns the last publish error if no node accepted the batch.
+func (p *producerPool) MultiPublish(topic string, bodies [][]byte) (err error) {
+	var start int
+	if p.partitionKey != nil && len(bodies) > 0 {
+		start = p.partition(p.partitionKey(topic, bodies[0]))
+	} else {
+		start = int(p.next.Add(1)-1) % len(p.producers)
+	}
+	return p.publishFrom(start, func(prod producer) error {
+		return prod.MultiPublish(topic, bodies)
+	})
+}
+
+// partition maps the key to a producer index deterministically.
+func (p *producerPool) partition(key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(len(p.producers)))
+}
+
+// publishFrom tries send on the producers in order beginning at start.
+func (p *producerPool) publishFrom(start int, send func(prod producer) error) (err error) {
+	now := time.Now().UnixNano()
+	var skipped []int
+	for i := range p.producers {
+		idx := (start + i) % len(p.producers)
+		if p.unhealthyUntil[idx].Load() > now {
+			skipped = append(skipped, idx)
+			continue
+		}
+		if err = p.publishTo(idx, send); err == nil {
+			return nil
+		}
+	}
+	for _, idx := range skipped {
+		if err = p.publishTo(idx, send); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// publishTo publishes through a single producer, updating its health from the result.
+func (p *producerPool) publishTo(idx int, send func(prod producer) error) (err error) {
+	if err = send(p.producers[idx]); err != nil {
+		p.unhealthyUntil[idx].Store(time.Now().Add(unhealthyCooldown).UnixNano())
+		return err
+	}
+	p.unhealthyUntil[idx].Store(0)
+	return nil
+}
+
+// Ping succeeds if at least one producer can reach its nsqd node.
+func (p *producerPool) Ping() (err error) {
+	for _, prod := range p.producers {
+		if err = prod.Ping(); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// Stop stops every producer in the pool.
+func (p *producerPool) Stop() {
+	for _, prod := range p.producers {
+		prod.Stop()
+	}
+}
@@ -0,0 +1,88 @@
+package nsq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// defaultMultiPublishBytes matches nsqd's default --max-body-size, the largest MPUB body it accepts.
+const defaultMultiPublishBytes = 5 * 1024 * 1024
+
+// PublishMany publishes the messages to the topic, splitting them into as few MultiPublish calls as possible
+// so that no call exceeds the limits set with WithMultiPublishLimit, by default nsqd's 5MB body size.
+// Messages keep their order within and across calls. A message larger than the byte limit is sent in a call of its own.
+// Every chunk is attempted even if an earlier one fails, except that with WithNonBlockingPublish publishing stops
+// at the first chunk that finds the producer busy. With ordered publishing the chunks are queued behind earlier calls.
+// Returns ErrNotConnected without a producer, ErrClosed after Close, ErrProducerBusy,
+// or the errors of the failed chunks joined together.
+func (c *Client) PublishMany(ctx context.Context, topic string, bodies [][]byte) (err error) {
+	var errs []error
+	for i, chunk := range chunkBodies(bodies, c.multiPublishBytes, c.multiPublishCount) {
+		if err = c.publishChunk(ctx, topic, chunk); err != nil {
+			if errors.Is(err, ErrNotConnected) || errors.Is(err, ErrClosed) || errors.Is(err, ErrProducerBusy) {
+				return err
+			}
+			errs = append(errs, fmt.Errorf("nsq: publish chunk %d (%d messages): %w", i, len(chunk), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// publishChunk sends a single MultiPublish, through the ordered publish queue when it is enabled.
+func (c *Client) publishChunk(ctx context.Context, topic string, chunk [][]byte) (err error) {
+	release, err := c.acquirePublish()
+	if err != nil {
+		return err
+	}
+	defer release()
+	if c.publishQueue == nil {
+		return c.multiPublish(topic, chunk)
+	}
+
+	req := &publishRequest{event: &NsqEvent{Topic: topic}, bodies: chunk, result: make(chan error, 1)}
+	select {
+	case c.publishQueue <- req:
+	case <-c.closing:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return <-req.result
+}
+
+// multiPublish sends the messages in one MultiPublish through the producer pool if one is configured, or Pub otherwise.
+func (c *Client) multiPublish(topic string, bodies [][]byte) (err error) {
+	if c.closed.Load() {
+		return ErrClosed
+	}
+	if c.pool != nil {
+		return c.pool.MultiPublish(topic, bodies)
+	}
+	if c.Pub == nil {
+		return ErrNotConnected
+	}
+	return c.Pub.MultiPublish(topic, bodies)
+}
+
+// chunkBodies splits the messages into consecutive chunks whose MPUB body, a 4-byte message count
+// followed by each message with a 4-byte length prefix, fits in maxBytes and that hold at most maxCount messages.
+// A limit of 0 or less is not enforced.
+func chunkBodies(bodies [][]byte, maxBytes, maxCount int) (chunks [][][]byte) {
+	var chunk [][]byte
+	size := 4
+	for _, body := range bodies {
+		overBytes := maxBytes > 0 && size+4+len(body) > maxBytes
+		overCount := maxCount > 0 && len(chunk) >= maxCount
+		if len(chunk) > 0 && (overBytes || overCount) {
+			chunks = append(chunks, chunk)
+			chunk, size = nil, 4
+		}
+		chunk = append(chunk, body)
+		size += 4 + len(body)
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
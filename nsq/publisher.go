@@ -2,10 +2,96 @@ package nsq
 
 import "context"
 
+// publishRequest is a single publish queued for the ordered publish worker.
+type publishRequest struct {
+	event  *NsqEvent
+	bodies [][]byte // the messages of a PublishMany chunk, sent with MultiPublish instead of event.Message
+	result chan error
+}
+
 // Publish sends a message to the specified NSQ topic.
 // It takes an NsqEvent containing the topic name and message content,
-// and publishes it using the underlying NSQ producer.
-// Returns an error if the publish operation fails.
+// and publishes it using the underlying NSQ producer, or the producer pool when several nodes are configured.
+// When ordered publishing is enabled the message is queued behind earlier calls.
+// Returns ErrNotConnected without a producer, ErrClosed after Close, ErrProducerBusy when WithNonBlockingPublish
+// is set and too many publishes are pending, or an error if the publish operation fails.
 func (c *Client) Publish(ctx context.Context, event *NsqEvent) (err error) {
-	return c.Pub.Publish(event.Topic, event.Message)
+	release, err := c.acquirePublish()
+	if err != nil {
+		return err
+	}
+	defer release()
+	if c.publishQueue == nil {
+		return c.publish(event.Topic, event.Message)
+	}
+
+	req := &publishRequest{event: event, result: make(chan error, 1)}
+	select {
+	case c.publishQueue <- req:
+	case <-c.closing:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return <-req.result
+}
+
+// acquirePublish claims a pending publish slot when non-blocking publishing is enabled, without waiting for one.
+// Returns the function that frees the slot, or ErrProducerBusy if every slot is taken.
+func (c *Client) acquirePublish() (release func(), err error) {
+	if c.publishSlots == nil {
+		return func() {}, nil
+	}
+	select {
+	case c.publishSlots <- struct{}{}:
+		return func() { <-c.publishSlots }, nil
+	default:
+		return nil, ErrProducerBusy
+	}
+}
+
+// runPublishQueue publishes queued requests one at a time, preserving their order, until the client is closed.
+func (c *Client) runPublishQueue() {
+	for {
+		select {
+		case req := <-c.publishQueue:
+			if req.bodies != nil {
+				req.result <- c.multiPublish(req.event.Topic, req.bodies)
+			} else {
+				req.result <- c.publish(req.event.Topic, req.event.Message)
+			}
+		case <-c.closing:
+			return
+		}
+	}
+}
+
+// Ping checks that nsqd is reachable by the producer.
+// With several nodes configured it succeeds if at least one node responds.
+// Returns ErrNotConnected without a producer, or an error if no node can be reached.
+func (c *Client) Ping(ctx context.Context) (err error) {
+	if c.closed.Load() {
+		return ErrClosed
+	}
+	if c.pool != nil {
+		return c.pool.Ping()
+	}
+	if c.Pub == nil {
+		return ErrNotConnected
+	}
+	return c.Pub.Ping()
+}
+
+// publish sends the message through the producer pool if one is configured, or Pub otherwise.
+func (c *Client) publish(topic string, body []byte) (err error) {
+	if c.closed.Load() {
+		return ErrClosed
+	}
+	if c.pool != nil {
+		return c.pool.Publish(topic, body)
+	}
+	if c.Pub == nil {
+		return ErrNotConnected
+	}
+	return c.Pub.Publish(topic, body)
 }
@@ -0,0 +1,89 @@
+package nsq
+
+import (
+	"sync"
+	"time"
+)
+
+// maxRampSteps bounds how many times a ramp changes MaxInFlight, however wide its range.
+const maxRampSteps = 20
+
+type (
+	// maxInFlightRamp holds the settings requested with WithMaxInFlightRamp.
+	maxInFlightRamp struct {
+		from     int
+		duration time.Duration
+	}
+
+	// inFlightRamp raises a consumer's MaxInFlight from a low value to its target in evenly spaced steps.
+	inFlightRamp struct {
+		consumer maxInFlightChanger
+		from, to int
+		duration time.Duration
+
+		mu      sync.Mutex
+		stopped bool
+		stop    chan struct{} // closed by halt to end the ramp early
+	}
+)
+
+// newInFlightRamp creates a ramp for the consumer from the requested start value up to the target.
+// The start value is clamped to between 1 and the target.
+func newInFlightRamp(cfg maxInFlightRamp, to int, consumer maxInFlightChanger) *inFlightRamp {
+	return &inFlightRamp{
+		consumer: consumer,
+		from:     min(max(cfg.from, 1), to),
+		to:       to,
+		duration: cfg.duration,
+		stop:     make(chan struct{}),
+	}
+}
+
+// start applies the initial MaxInFlight and raises it in the background until the target is reached or halt is called.
+func (r *inFlightRamp) start() {
+	r.consumer.ChangeMaxInFlight(r.from)
+	steps := min(r.to-r.from, maxRampSteps)
+	if steps <= 0 || r.duration <= 0 {
+		r.apply(r.to)
+		return
+	}
+	go r.run(steps, r.duration/time.Duration(steps))
+}
+
+// run raises MaxInFlight by an even share of the range every interval.
+func (r *inFlightRamp) run(steps int, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for step := 1; step <= steps; step++ {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+		}
+		if !r.apply(r.from + (r.to-r.from)*step/steps) {
+			return
+		}
+	}
+}
+
+// apply sets MaxInFlight unless the ramp has been halted, reporting whether it did.
+func (r *inFlightRamp) apply(maxInFlight int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped {
+		return false
+	}
+	r.consumer.ChangeMaxInFlight(maxInFlight)
+	return true
+}
+
+// halt ends the ramp. Once it returns the ramp no longer changes MaxInFlight,
+// so Drain can set it to zero without being overridden.
+func (r *inFlightRamp) halt() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.stopped {
+		r.stopped = true
+		close(r.stop)
+	}
+}
@@ -0,0 +1,162 @@
+package nsq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nsqio/go-nsq"
+)
+
+var (
+	// ErrReceiverStopped is returned by Receive when its consumer is stopped by Stop, Drain or Close while waiting.
+	ErrReceiverStopped = errors.New("nsq: receiver stopped")
+)
+
+// receiver hands messages from a consumer started by Receive to its callers.
+type receiver struct {
+	messages chan *ReceivedMessage
+	stop     chan struct{} // closed when the consumer is stopped
+	consumer *nsq.Consumer
+}
+
+// ReceivedMessage is a message pulled with Receive whose outcome is decided by the caller
+// through Ack or Nack instead of a handler's return value.
+type ReceivedMessage struct {
+	Body    []byte            // The message content, unwrapped from its envelope if it had one
+	Headers map[string]string // The envelope headers, nil for plain messages
+	Info    *MessageInfo      // Delivery metadata such as attempts and queue latency
+
+	message *nsq.Message
+}
+
+// Ack finishes the message so nsqd does not deliver it again.
+func (m *ReceivedMessage) Ack() {
+	m.message.Finish()
+}
+
+// Nack requeues the message so nsqd delivers it again after delay.
+// A negative delay lets nsqd compute the backoff from the number of attempts.
+func (m *ReceivedMessage) Nack(delay time.Duration) {
+	m.message.Requeue(delay)
+}
+
+// Touch resets the message's nsqd timeout, for callers that need longer than the message timeout to decide.
+func (m *ReceivedMessage) Touch() {
+	m.message.Touch()
+}
+
+// Receive waits for the next message on the topic and channel and returns it without acknowledging it,
+// so the caller can commit a downstream side effect before calling Ack, or Nack to retry later.
+// The first call for a topic and channel starts a consumer that hands messages to Receive one at a time;
+// it is stopped by Stop, Drain or Close like any registered consumer.
+// A message that is neither acknowledged nor touched within the message timeout is redelivered by nsqd.
+// Returns the context error if ctx is done first, ErrReceiverStopped if the consumer is stopped while waiting, ErrAlreadyRegistered if RegisterConsumer already consumes
+// the topic and channel, or an error if the consumer creation or connection fails.
+func (c *Client) Receive(ctx context.Context, topic, channel string) (received *ReceivedMessage, err error) {
+	r, err := c.receiver(topic, channel)
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case received = <-r.messages:
+		return received, nil
+	case <-r.stop:
+		return nil, ErrReceiverStopped
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// receiver returns the receiver for the topic and channel, starting its consumer on first use.
+func (c *Client) receiver(topic, channel string) (r *receiver, err error) {
+	if c.Config == nil {
+		return nil, ErrNotConnected
+	}
+	key := topic + "/" + channel
+
+	c.mu.Lock()
+	if r, ok := c.receivers[key]; ok {
+		c.mu.Unlock()
+		return r, nil
+	}
+	if _, ok := c.registered[key]; ok {
+		c.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrAlreadyRegistered, key)
+	}
+	if c.registered == nil {
+		c.registered = make(map[string]struct{})
+	}
+	if c.receivers == nil {
+		c.receivers = make(map[string]*receiver)
+	}
+	r = &receiver{messages: make(chan *ReceivedMessage), stop: make(chan struct{})}
+	c.registered[key] = struct{}{}
+	c.receivers[key] = r
+	c.mu.Unlock()
+
+	defer func() {
+		if err != nil {
+			c.mu.Lock()
+			delete(c.receivers, key)
+			c.mu.Unlock()
+			c.unregister(topic, channel)
+		}
+	}()
+
+	config, err := newConsumerOptions().consumerConfig(c.Config)
+	if err != nil {
+		return nil, err
+	}
+	consumer, err := nsq.NewConsumer(topic, channel, config)
+	if err != nil {
+		return nil, err
+	}
+	consumer.AddHandler(nsq.HandlerFunc(func(message *nsq.Message) error {
+		message.DisableAutoResponse()
+		envelope := openEnvelope(message.Body)
+		info := newMessageInfo(message, time.Now())
+		info.SchemaVersion, info.ContentType = envelope.SchemaVersion, envelope.ContentType
+		select {
+		case r.messages <- &ReceivedMessage{Body: envelope.Body, Headers: envelope.Headers, Info: info, message: message}:
+		case <-r.stop:
+			message.Requeue(-1)
+		}
+		return nil
+	}))
+	if err = consumer.ConnectToNSQLookupd(c.Lookupd); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	r.consumer = consumer
+	c.consumers = append(c.consumers, consumer)
+	c.mu.Unlock()
+	return r, nil
+}
+
+// stopReceiver stops the consumer started by Receive for the topic and channel, if any,
+// requeueing the message it is holding, and releases the pair so it can be consumed again.
+func (c *Client) stopReceiver(topic, channel string) {
+	key := topic + "/" + channel
+	c.mu.Lock()
+	r, ok := c.receivers[key]
+	if !ok || r.consumer == nil {
+		c.mu.Unlock()
+		return
+	}
+	delete(c.receivers, key)
+	delete(c.registered, key)
+	for i, consumer := range c.consumers {
+		if consumer == r.consumer {
+			c.consumers = append(c.consumers[:i], c.consumers[i+1:]...)
+			break
+		}
+	}
+	close(r.stop)
+	c.mu.Unlock()
+
+	r.consumer.Stop()
+	<-r.consumer.StopChan
+}
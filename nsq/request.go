@@ -0,0 +1,141 @@
+package nsq
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	CorrelationIDHeader = "correlation_id" // Header matching a reply to its request
+	ReplyToHeader       = "reply_to"       // Header naming the topic a request's reply is published to
+)
+
+var (
+	// ErrRequestTimeout is returned by Request when no matching reply arrives within the timeout.
+	ErrRequestTimeout = errors.New("nsq: timed out waiting for reply")
+	// ErrNoReplyTo is returned by Reply when the message being handled was not sent with Request.
+	ErrNoReplyTo = errors.New("nsq: message has no reply topic")
+)
+
+// replyDispatcher consumes a reply topic and hands each reply to the Request waiting for its correlation ID.
+type replyDispatcher struct {
+	mu      sync.Mutex
+	pending map[string]chan []byte // waiting requests by correlation ID
+}
+
+// Request publishes body to the topic with a fresh correlation ID and replyTopic as its reply topic,
+// then waits until a reply carrying the same correlation ID is published to replyTopic, for request/reply over NSQ.
+// Responders answer with Reply from their handler. The first Request for a reply topic subscribes to it
+// on an ephemeral channel unique to this client, so every requesting instance receives each reply
+// and picks out its own; replies nobody is waiting for, such as late ones, are discarded.
+// The reply consumer is stopped by Stop, Drain or Close like any registered consumer.
+// Returns the reply body, ErrRequestTimeout if none arrives within timeout, the context error if ctx is done first,
+// or an error if subscribing to the reply topic or publishing fails.
+func (c *Client) Request(ctx context.Context, topic string, body []byte, replyTopic string, timeout time.Duration) (reply []byte, err error) {
+	dispatcher, err := c.replyDispatcher(replyTopic)
+	if err != nil {
+		return nil, err
+	}
+	id, err := newCorrelationID()
+	if err != nil {
+		return nil, err
+	}
+
+	replies := make(chan []byte, 1)
+	dispatcher.mu.Lock()
+	dispatcher.pending[id] = replies
+	dispatcher.mu.Unlock()
+	defer func() {
+		dispatcher.mu.Lock()
+		delete(dispatcher.pending, id)
+		dispatcher.mu.Unlock()
+	}()
+
+	headers := map[string]string{CorrelationIDHeader: id, ReplyToHeader: replyTopic}
+	if err = c.PublishWithHeaders(ctx, topic, headers, body); err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case reply = <-replies:
+		return reply, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("%w after %s on topic %s", ErrRequestTimeout, timeout, replyTopic)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Reply publishes body as the reply to the request being handled, to the reply topic and with the
+// correlation ID the request was sent with. ctx must be the context passed to the handler.
+// Returns ErrNoReplyTo if the message was not sent with Request, or an error if the publish fails.
+func (c *Client) Reply(ctx context.Context, body []byte) (err error) {
+	headers := HeadersFromContext(ctx)
+	replyTo, id := headers[ReplyToHeader], headers[CorrelationIDHeader]
+	if replyTo == "" || id == "" {
+		return ErrNoReplyTo
+	}
+	return c.PublishWithHeaders(ctx, replyTo, map[string]string{CorrelationIDHeader: id}, body)
+}
+
+// replyDispatcher returns the dispatcher for the reply topic, subscribing to it on first use.
+func (c *Client) replyDispatcher(replyTopic string) (dispatcher *replyDispatcher, err error) {
+	c.replyMu.Lock()
+	defer c.replyMu.Unlock()
+	c.mu.Lock()
+	dispatcher, ok := c.replies[replyTopic]
+	c.mu.Unlock()
+	if ok {
+		return dispatcher, nil
+	}
+
+	suffix, err := newCorrelationID()
+	if err != nil {
+		return nil, err
+	}
+	dispatcher = &replyDispatcher{pending: make(map[string]chan []byte)}
+	channel := "reply-" + suffix[:8] + ephemeralSuffix
+	err = c.subscribe(replyTopic, channel, func(ctx context.Context, topic string) error {
+		body, _ := ctx.Value(topic).(string)
+		dispatcher.deliver(HeadersFromContext(ctx)[CorrelationIDHeader], []byte(body))
+		return nil
+	}, newConsumerOptions())
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	if c.replies == nil {
+		c.replies = make(map[string]*replyDispatcher)
+	}
+	c.replies[replyTopic] = dispatcher
+	c.mu.Unlock()
+	return dispatcher, nil
+}
+
+// deliver hands the reply to the request waiting for the correlation ID, if any.
+func (d *replyDispatcher) deliver(id string, reply []byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if replies, ok := d.pending[id]; ok {
+		select {
+		case replies <- reply:
+		default:
+		}
+	}
+}
+
+// newCorrelationID returns a random hex identifier.
+func newCorrelationID() (id string, err error) {
+	buf := make([]byte, 16)
+	if _, err = rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,70 @@
+package nsq
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+)
+
+// Router dispatches messages of a single topic to different handlers based on
+// the JSON `type` field of the message body, avoiding a switch in every ConsumerFunc.
+// Its Route method is itself a ConsumerFunc and is passed to RegisterConsumer.
+type Router struct {
+	mu       sync.RWMutex
+	handlers map[string]ConsumerFunc
+	fallback ConsumerFunc
+}
+
+// routedMessage is the part of a message body the Router looks at.
+type routedMessage struct {
+	Type string `json:"type"`
+}
+
+// NewRouter creates an empty Router. Without a fallback, messages of unknown type are acknowledged and skipped.
+func NewRouter() *Router {
+	return &Router{
+		handlers: make(map[string]ConsumerFunc),
+	}
+}
+
+// Handle registers the ConsumerFunc for messages whose `type` field equals messageType,
+// replacing any handler previously registered for it. It returns the Router for chaining.
+func (r *Router) Handle(messageType string, cf ConsumerFunc) *Router {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[messageType] = cf
+	return r
+}
+
+// Fallback registers the ConsumerFunc for messages with an unknown, missing or unparsable type.
+// It returns the Router for chaining.
+func (r *Router) Fallback(cf ConsumerFunc) *Router {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fallback = cf
+	return r
+}
+
+// Route reads the message for the topic from the context, like Consume, and dispatches it
+// to the handler registered for its type. Messages without a matching handler go to the fallback,
+// or are logged and acknowledged when no fallback is set.
+// Returns the handler's error, so a failing handler requeues the message as usual.
+func (r *Router) Route(ctx context.Context, topic string) (err error) {
+	body, _ := ctx.Value(topic).(string)
+	message := &routedMessage{}
+	_ = json.Unmarshal([]byte(body), message)
+
+	r.mu.RLock()
+	cf, ok := r.handlers[message.Type]
+	if !ok {
+		cf = r.fallback
+	}
+	r.mu.RUnlock()
+
+	if cf == nil {
+		log.Printf("Skipping message of unknown type %q on topic %s", message.Type, topic)
+		return nil
+	}
+	return cf(ctx, topic)
+}
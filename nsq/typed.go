@@ -0,0 +1,101 @@
+package nsq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Content types understood by Publisher and Handle.
+const (
+	ContentTypeJSON    = "application/json"
+	ContentTypeMsgpack = "application/msgpack"
+)
+
+var (
+	// ErrUnsupportedContentType is returned by handlers built with Handle for a payload whose content type
+	// has no codec. Such messages are moved to the dead-letter topic right away when the consumer has WithDeadLetter,
+	// and dropped with a logged warning otherwise, since retrying cannot succeed.
+	ErrUnsupportedContentType = errors.New("nsq: unsupported content type")
+)
+
+// Publisher publishes values of type T to a topic, encoded in a fixed content type
+// that is recorded in the message envelope so consumers know how to decode it.
+type Publisher[T any] struct {
+	client      NSQ
+	topic       string
+	contentType string
+}
+
+// NewPublisher creates a Publisher of T values to the topic, encoded as contentType,
+// ContentTypeJSON or ContentTypeMsgpack; an empty content type means JSON.
+// Returns ErrUnsupportedContentType for any other content type.
+func NewPublisher[T any](client NSQ, topic, contentType string) (publisher *Publisher[T], err error) {
+	if contentType == "" {
+		contentType = ContentTypeJSON
+	}
+	if _, err = codecFor(contentType); err != nil {
+		return nil, err
+	}
+	return &Publisher[T]{client: client, topic: topic, contentType: contentType}, nil
+}
+
+// Publish encodes the value and publishes it in an Envelope carrying the content type.
+// Returns an error if encoding or the publish operation fails.
+func (p *Publisher[T]) Publish(ctx context.Context, value T) (err error) {
+	codec, err := codecFor(p.contentType)
+	if err != nil {
+		return err
+	}
+	body, err := codec.marshal(value)
+	if err != nil {
+		return fmt.Errorf("nsq: encode %s payload: %w", p.contentType, err)
+	}
+	return p.client.PublishEnvelope(ctx, p.topic, Envelope{ContentType: p.contentType, Body: body})
+}
+
+// Handle adapts handler into a ConsumerFunc that decodes each message into a T
+// with the codec named by its envelope's content type, so one consumer accepts payloads
+// from producers using different encodings. Messages without a content type, including plain
+// messages published without an envelope, are decoded as JSON.
+// A message with an unknown content type fails with ErrUnsupportedContentType without reaching handler,
+// and a message that cannot be decoded fails with the decoding error and is requeued as usual.
+func Handle[T any](handler func(ctx context.Context, value T) error) ConsumerFunc {
+	return func(ctx context.Context, topic string) error {
+		contentType := ContentTypeJSON
+		if info, ok := MessageInfoFromContext(ctx); ok && info.ContentType != "" {
+			contentType = info.ContentType
+		}
+		codec, err := codecFor(contentType)
+		if err != nil {
+			return err
+		}
+		body, _ := ctx.Value(topic).(string)
+		var value T
+		if err = codec.unmarshal([]byte(body), &value); err != nil {
+			return fmt.Errorf("nsq: decode %s payload: %w", contentType, err)
+		}
+		return handler(ctx, value)
+	}
+}
+
+// payloadCodec encodes and decodes payloads of one content type.
+type payloadCodec struct {
+	marshal   func(value interface{}) ([]byte, error)
+	unmarshal func(data []byte, value interface{}) error
+}
+
+// codecFor returns the codec for the content type.
+func codecFor(contentType string) (codec payloadCodec, err error) {
+	switch contentType {
+	case ContentTypeJSON:
+		return payloadCodec{marshal: json.Marshal, unmarshal: json.Unmarshal}, nil
+	case ContentTypeMsgpack, "application/x-msgpack":
+		return payloadCodec{marshal: msgpack.Marshal, unmarshal: msgpack.Unmarshal}, nil
+	default:
+		return payloadCodec{}, fmt.Errorf("%w: %q", ErrUnsupportedContentType, contentType)
+	}
+}
@@ -0,0 +1,79 @@
+package nsq
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// NewNSQFromURL creates a client from a DSN such as
+//
+//	nsq://nsqd-host:4150?lookupd=lookupd-host:4161&maxInFlight=50
+//
+// so the broker can be configured from a single environment variable.
+// The host and port name the nsqd node to publish to. The query parameters are:
+//
+//   - lookupd: the nsqlookupd HTTP address consumers discover nsqd through, required
+//   - nodes: a comma-separated list of further nsqd addresses to spread publishes across
+//   - dialTimeout: as NSQConfig.DialTimeout, e.g. "2s"
+//
+// Any other parameter is applied to the go-nsq configuration by name, in camelCase or snake_case,
+// e.g. maxInFlight, max_attempts or heartbeatInterval. Options in opts are applied as with NewNSQClient.
+// Returns an error if the DSN cannot be parsed, a parameter is unknown or invalid, or initialization fails.
+func NewNSQFromURL(dsn string, opts ...Option) (result NSQ, err error) {
+	parsed, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("nsq: parse dsn: %w", err)
+	}
+	if parsed.Scheme != "nsq" {
+		return nil, fmt.Errorf("nsq: unsupported dsn scheme %q", parsed.Scheme)
+	}
+	host, port, err := net.SplitHostPort(parsed.Host)
+	if err != nil {
+		return nil, fmt.Errorf("nsq: parse dsn: %w", err)
+	}
+
+	config := &NSQConfig{Host: host, DTCPPort: port}
+	var settings []configSetting
+	for name, values := range parsed.Query() {
+		value := values[len(values)-1]
+		switch name {
+		case "lookupd":
+			config.LookupdAddr = value
+		case "nodes":
+			config.Nodes = append([]string{parsed.Host}, strings.Split(value, ",")...)
+		case "dialTimeout", "dial_timeout":
+			if config.DialTimeout, err = time.ParseDuration(value); err != nil {
+				return nil, fmt.Errorf("nsq: invalid dsn parameter %s: %w", name, err)
+			}
+		default:
+			settings = append(settings, configSetting{name: snakeCase(name), value: value})
+		}
+	}
+	if config.LookupdAddr == "" {
+		return nil, fmt.Errorf("nsq: dsn %q has no lookupd parameter", parsed.Redacted())
+	}
+
+	withSettings := func(o *options) {
+		o.settings = append(o.settings, settings...)
+	}
+	return NewNSQClient(config, append([]Option{withSettings}, opts...)...)
+}
+
+// snakeCase converts a camelCase parameter name to the snake_case name go-nsq options use.
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
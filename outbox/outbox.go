@@ -0,0 +1,173 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"log"
+	"time"
+)
+
+const (
+	// defaultInterval is how often Run relays pending messages when no interval is configured.
+	defaultInterval = time.Second
+	// defaultBatchSize is the most messages a single relay pass publishes when no batch size is configured.
+	defaultBatchSize = 100
+)
+
+type (
+	// Publisher sends a message to a topic. It is satisfied by broker.MessageBroker implementations
+	// such as nsq.Broker and stream.Broker.
+	Publisher interface {
+		Publish(ctx context.Context, topic string, msg []byte) (err error)
+	}
+
+	// Message is a message waiting in the outbox to be published.
+	Message struct {
+		Topic string `json:"topic"` // The topic the message is published to
+		Body  []byte `json:"body"`  // The message content
+	}
+
+	// Outbox stores messages in a Redis list in the same transaction as a business write,
+	// and relays them to a Publisher afterwards, so a message is published if and only if the write happened,
+	// even when the broker is unavailable at write time.
+	Outbox struct {
+		client    *redis.Client
+		key       string
+		publisher Publisher
+		interval  time.Duration
+		batchSize int
+	}
+
+	// Option configures optional behaviour of an Outbox.
+	Option func(*Outbox)
+)
+
+// WithInterval sets how often Run relays pending messages, 1s by default.
+func WithInterval(interval time.Duration) Option {
+	return func(o *Outbox) {
+		if interval > 0 {
+			o.interval = interval
+		}
+	}
+}
+
+// WithBatchSize sets the most messages a single relay pass publishes, 100 by default.
+func WithBatchSize(size int) Option {
+	return func(o *Outbox) {
+		if size > 0 {
+			o.batchSize = size
+		}
+	}
+}
+
+// Write runs the business write queued by fn and appends the messages to the outbox in a single
+// MULTI/EXEC transaction, so either both are applied or neither is. fn must only queue commands on pipe;
+// their results are available once Write returns. A nil fn only enqueues the messages.
+// Returns an error if encoding a message, fn or the transaction fails, in which case nothing was written.
+func (o *Outbox) Write(ctx context.Context, fn func(pipe redis.Pipeliner) error, messages ...Message) (err error) {
+	entries := make([]interface{}, len(messages))
+	for i, message := range messages {
+		if entries[i], err = json.Marshal(&message); err != nil {
+			return fmt.Errorf("outbox: encode message %d: %w", i, err)
+		}
+	}
+	_, err = o.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if fn != nil {
+			if err := fn(pipe); err != nil {
+				return err
+			}
+		}
+		if len(entries) > 0 {
+			pipe.RPush(ctx, o.key, entries...)
+		}
+		return nil
+	})
+	return err
+}
+
+// Relay publishes up to a batch of pending messages in the order they were written,
+// removing each from the outbox once the publisher accepted it. It stops at the first failed publish
+// so the failed message, and those after it, stay in the outbox for the next pass.
+// A message that cannot be decoded is logged and removed, since it can never be published.
+// Delivery is at-least-once: a message is published again if removing it fails, or if several relays
+// run against the same outbox, so consumers should be idempotent.
+// Returns the number of messages published, and an error if reading the outbox or a publish fails.
+func (o *Outbox) Relay(ctx context.Context) (published int, err error) {
+	for published < o.batchSize {
+		entry, err := o.client.LIndex(ctx, o.key, 0).Bytes()
+		if errors.Is(err, redis.Nil) {
+			return published, nil
+		}
+		if err != nil {
+			return published, err
+		}
+
+		message := &Message{}
+		if err = json.Unmarshal(entry, message); err != nil {
+			log.Printf("Discarding undecodable outbox entry in %s: %v", o.key, err)
+		} else if err = o.publisher.Publish(ctx, message.Topic, message.Body); err != nil {
+			return published, fmt.Errorf("outbox: publish to %s: %w", message.Topic, err)
+		} else {
+			published++
+		}
+
+		// LREM removes this exact entry even if another relay already popped the head.
+		if err = o.client.LRem(ctx, o.key, 1, entry).Err(); err != nil {
+			return published, err
+		}
+	}
+	return published, nil
+}
+
+// Run relays pending messages every interval until ctx is done, logging failed passes;
+// messages that failed to publish are retried on the next pass.
+// Returns the context error once ctx is done.
+func (o *Outbox) Run(ctx context.Context) (err error) {
+	ticker := time.NewTicker(o.interval)
+	defer ticker.Stop()
+	for {
+		for {
+			published, err := o.Relay(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("Failed to relay outbox %s: %v", o.key, err)
+				}
+				break
+			}
+			if published < o.batchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Pending returns the number of messages waiting in the outbox.
+// Returns an error if the command fails.
+func (o *Outbox) Pending(ctx context.Context) (count int64, err error) {
+	return o.client.LLen(ctx, o.key).Result()
+}
+
+// NewOutbox creates an outbox storing pending messages in the Redis list at key
+// and relaying them to publisher. Start relaying by running Run on a goroutine.
+// Returns the Outbox with the options applied.
+func NewOutbox(client *redis.Client, key string, publisher Publisher, opts ...Option) *Outbox {
+	o := &Outbox{
+		client:    client,
+		key:       key,
+		publisher: publisher,
+		interval:  defaultInterval,
+		batchSize: defaultBatchSize,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
@@ -0,0 +1,111 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type (
+	// Closer is implemented by components that release their resources within the context's deadline,
+	// such as a drained NSQ client.
+	Closer interface {
+		// Close stops the component, giving up when ctx is done.
+		Close(ctx context.Context) (err error)
+	}
+
+	// CloserFunc adapts a function to the Closer interface, e.g. an NSQ client's Drain method.
+	CloserFunc func(ctx context.Context) (err error)
+
+	// component is a registered Closer and the name it is reported under.
+	component struct {
+		name   string
+		closer Closer
+	}
+
+	// Shutdowner shuts down the registered components one after another, in registration order,
+	// under a single deadline. Register consumers first so they drain before the producers and caches
+	// their handlers use are closed.
+	Shutdowner struct {
+		mu         sync.Mutex
+		timeout    time.Duration
+		components []component
+		once       sync.Once
+		err        error
+	}
+)
+
+// Close calls f.
+func (f CloserFunc) Close(ctx context.Context) (err error) {
+	return f(ctx)
+}
+
+// Blocking adapts a Close method without a context, such as an NSQ client's or an in-memory cache's,
+// to the Closer interface. If ctx is done first the shutdown moves on without waiting for closeFn to return.
+func Blocking(closeFn func() error) Closer {
+	return CloserFunc(func(ctx context.Context) error {
+		done := make(chan error, 1)
+		go func() {
+			done <- closeFn()
+		}()
+		select {
+		case err := <-done:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+// Register adds the component under name. Components are shut down in the order they are registered.
+func (s *Shutdowner) Register(name string, closer Closer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.components = append(s.components, component{name: name, closer: closer})
+}
+
+// Shutdown closes every registered component in registration order, each waiting for the previous one,
+// all within the Shutdowner's timeout and ctx. A failing component does not stop the ones after it;
+// once the deadline has passed the remaining components are skipped and reported as not closed.
+// Only the first call shuts down; later calls return its result.
+// Returns the errors of the components that failed or were skipped, joined and labeled with their names.
+func (s *Shutdowner) Shutdown(ctx context.Context) (err error) {
+	s.once.Do(func() {
+		s.err = s.shutdown(ctx)
+	})
+	return s.err
+}
+
+// shutdown closes the components in order under the combined deadline.
+func (s *Shutdowner) shutdown(ctx context.Context) (err error) {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	s.mu.Lock()
+	components := append([]component(nil), s.components...)
+	s.mu.Unlock()
+
+	var errs []error
+	for _, c := range components {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			errs = append(errs, fmt.Errorf("shutdown: %s not closed: %w", c.name, ctxErr))
+			continue
+		}
+		if closeErr := c.closer.Close(ctx); closeErr != nil {
+			errs = append(errs, fmt.Errorf("shutdown: close %s: %w", c.name, closeErr))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NewShutdowner creates a Shutdowner whose Shutdown gives all components together at most timeout to close.
+// A timeout of 0 or less only bounds shutdown by the context passed to Shutdown.
+// Returns an empty Shutdowner ready for Register.
+func NewShutdowner(timeout time.Duration) *Shutdowner {
+	return &Shutdowner{timeout: timeout}
+}
@@ -0,0 +1,23 @@
+package stream
+
+import (
+	"context"
+	"github.com/RandySteven/common_go/broker"
+)
+
+// Broker adapts a Redis Streams client to the broker.MessageBroker interface.
+type Broker struct {
+	Stream
+}
+
+// Publish appends a message to the specified topic.
+func (b *Broker) Publish(ctx context.Context, topic string, msg []byte) (err error) {
+	return b.Stream.Publish(ctx, &StreamEvent{Topic: topic, Message: msg})
+}
+
+var _ broker.MessageBroker = &Broker{}
+
+// NewBroker wraps the Redis Streams client so it can be used wherever a broker.MessageBroker is expected.
+func NewBroker(client Stream) broker.MessageBroker {
+	return &Broker{Stream: client}
+}
@@ -0,0 +1,284 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/RandySteven/common_go/broker"
+	"github.com/redis/go-redis/v9"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// messageField is the stream entry field holding the message body.
+	messageField = "message"
+	// defaultBlock is how long XREADGROUP waits for new entries before polling again.
+	defaultBlock = 5 * time.Second
+	// readCount is the maximum number of entries read per XREADGROUP or XAUTOCLAIM call.
+	readCount = 10
+	// defaultClaimIdle is how long an entry stays pending before it is claimed and handled again.
+	// It exceeds the 30s handler timeout, so entries whose handler is still running are not claimed.
+	defaultClaimIdle = time.Minute
+)
+
+var (
+	// ErrAlreadyRegistered is returned when a consumer is registered twice for the same topic and group.
+	ErrAlreadyRegistered = errors.New("stream: consumer already registered for topic and group")
+)
+
+type (
+	// ConsumerFunc defines the signature for a consumer function that processes messages
+	// from a specific topic. It receives a context and topic name, and returns an error.
+	// As with the NSQ client, the message body is stored in the context under the topic name.
+	ConsumerFunc func(ctx context.Context, topic string) (err error)
+
+	// StreamEvent represents a message event with topic and message content.
+	StreamEvent struct {
+		Topic   string // The stream key where the message will be published
+		Message []byte // The actual message content as bytes
+	}
+
+	// Stream defines the interface for Redis Streams operations including publishing, consuming,
+	// and registering consumers. It mirrors the NSQ interface so callers can swap transports via config.
+	Stream interface {
+		// Publish appends a message to the specified topic
+		Publish(ctx context.Context, event *StreamEvent) (err error)
+		// Consume retrieves a message from the specified topic
+		Consume(ctx context.Context, topic string) (value string, err error)
+		// RegisterConsumer sets up a consumer function for a topic within a consumer group
+		RegisterConsumer(topic, group string, cf ConsumerFunc) (err error)
+		// Subscribe runs handler for every message delivered to the group on the specified topic
+		Subscribe(ctx context.Context, topic, group string, handler broker.Handler) (err error)
+		// Stop stops all registered consumers and waits for their handlers to return
+		Stop()
+		// Ping checks that Redis is reachable
+		Ping(ctx context.Context) (err error)
+	}
+
+	// Client represents a Redis Streams client that handles publishing and consuming messages.
+	Client struct {
+		Redis    *redis.Client // Redis client used for the stream commands
+		Consumer string        // Consumer name reported to the consumer groups
+
+		block      time.Duration
+		claimIdle  time.Duration
+		mu         sync.Mutex                    // guards registered
+		registered map[string]context.CancelFunc // cancels the read loop of each topic/group pair
+		wg         sync.WaitGroup                // tracks running read loops
+	}
+
+	// StreamConfig holds configuration parameters for connecting to Redis.
+	StreamConfig struct {
+		Host     string // Redis host address
+		Port     string // Redis port
+		Consumer string // Consumer name within the groups, e.g. the hostname; defaults to "consumer"
+
+		// Block bounds how long a consumer waits for new entries per read, defaulting to 5s.
+		Block time.Duration
+		// ClaimIdle is how long an entry must have been pending, because its handler failed or its consumer
+		// crashed, before it is claimed and handled again, defaulting to 1m.
+		ClaimIdle time.Duration
+	}
+)
+
+// Publish appends a message to the stream named by the event topic using XADD.
+// Returns an error if the append fails.
+func (c *Client) Publish(ctx context.Context, event *StreamEvent) (err error) {
+	return c.Redis.XAdd(ctx, &redis.XAddArgs{
+		Stream: event.Topic,
+		Values: map[string]interface{}{messageField: event.Message},
+	}).Err()
+}
+
+// Consume retrieves a message from the specified topic by checking the context.
+// It is used within consumer handlers to access the received message, like the NSQ client's Consume.
+func (c *Client) Consume(ctx context.Context, topic string) (value string, err error) {
+	value, ok := ctx.Value(topic).(string)
+	if !ok {
+		return "", fmt.Errorf(`failed to consume the topic %s`, topic)
+	}
+	return value, nil
+}
+
+// RegisterConsumer creates the consumer group for the topic if needed and starts reading
+// new entries with XREADGROUP, running cf for each one.
+// Entries are acknowledged with XACK when cf succeeds. Entries whose handler fails stay pending
+// in the group and are retried: every ClaimIdle the consumer claims the group's entries that have been pending
+// for longer than ClaimIdle with XAUTOCLAIM, including those left behind by crashed consumers, and handles them again.
+// Returns ErrAlreadyRegistered if a consumer already exists for the topic and group,
+// or an error if the group cannot be created.
+func (c *Client) RegisterConsumer(topic, group string, cf ConsumerFunc) (err error) {
+	err = c.Redis.XGroupCreateMkStream(context.Background(), topic, group, "$").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.mu.Lock()
+	if c.registered == nil {
+		c.registered = make(map[string]context.CancelFunc)
+	}
+	key := topic + "/" + group
+	if _, ok := c.registered[key]; ok {
+		c.mu.Unlock()
+		cancel()
+		return fmt.Errorf("%w: %s", ErrAlreadyRegistered, key)
+	}
+	c.registered[key] = cancel
+	c.mu.Unlock()
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.readLoop(ctx, topic, group, cf)
+	}()
+	return nil
+}
+
+// Subscribe registers handler as a consumer of the topic within the group,
+// passing it the message body instead of the topic name.
+// The context only bounds registration; the consumer runs until Stop.
+// Returns ErrAlreadyRegistered if a consumer already exists for the topic and group.
+func (c *Client) Subscribe(ctx context.Context, topic, group string, handler broker.Handler) (err error) {
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	return c.RegisterConsumer(topic, group, func(ctx context.Context, t string) error {
+		body, _ := ctx.Value(t).(string)
+		return handler(ctx, []byte(body))
+	})
+}
+
+// readLoop reads new entries for the group until ctx is cancelled,
+// reclaiming stale pending entries on start and every claimIdle.
+func (c *Client) readLoop(ctx context.Context, topic, group string, cf ConsumerFunc) {
+	claimIdle := c.claimIdle
+	if claimIdle <= 0 {
+		claimIdle = defaultClaimIdle
+	}
+	var lastClaim time.Time
+	for ctx.Err() == nil {
+		if time.Since(lastClaim) >= claimIdle {
+			c.reclaim(ctx, topic, group, claimIdle, cf)
+			lastClaim = time.Now()
+		}
+		streams, err := c.Redis.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    group,
+			Consumer: c.Consumer,
+			Streams:  []string{topic, ">"},
+			Count:    readCount,
+			Block:    c.block,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Error reading stream %s: %v", topic, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		for _, stream := range streams {
+			for _, message := range stream.Messages {
+				c.handle(topic, group, message, cf)
+			}
+		}
+	}
+}
+
+// reclaim claims the group's entries that have been pending for longer than minIdle and handles them again,
+// paging through the pending entries list until it is exhausted or ctx is cancelled.
+func (c *Client) reclaim(ctx context.Context, topic, group string, minIdle time.Duration, cf ConsumerFunc) {
+	start := "0-0"
+	for ctx.Err() == nil {
+		messages, next, err := c.Redis.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   topic,
+			Group:    group,
+			Consumer: c.Consumer,
+			MinIdle:  minIdle,
+			Start:    start,
+			Count:    readCount,
+		}).Result()
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Error claiming pending entries of stream %s: %v", topic, err)
+			}
+			return
+		}
+		for _, message := range messages {
+			c.handle(topic, group, message, cf)
+		}
+		if next == "0-0" {
+			return
+		}
+		start = next
+	}
+}
+
+// handle runs cf for a single entry and acknowledges it on success.
+func (c *Client) handle(topic, group string, message redis.XMessage, cf ConsumerFunc) {
+	body, _ := message.Values[messageField].(string)
+	handlerCtx := context.WithValue(context.Background(), topic, body)
+	handlerCtx, cancel := context.WithTimeout(handlerCtx, time.Second*30)
+	defer cancel()
+
+	if err := cf(handlerCtx, topic); err != nil {
+		log.Println("Error in handlerFunc:", err)
+		return
+	}
+	if err := c.Redis.XAck(context.Background(), topic, group, message.ID).Err(); err != nil {
+		log.Printf("Failed to ack message %s on stream %s: %v", message.ID, topic, err)
+	}
+}
+
+// Stop cancels every registered consumer and waits for their current handlers to return.
+func (c *Client) Stop() {
+	c.mu.Lock()
+	for _, cancel := range c.registered {
+		cancel()
+	}
+	c.registered = nil
+	c.mu.Unlock()
+	c.wg.Wait()
+}
+
+// Ping checks that Redis is reachable.
+func (c *Client) Ping(ctx context.Context) (err error) {
+	return c.Redis.Ping(ctx).Err()
+}
+
+var _ Stream = &Client{}
+
+// NewStreamClient creates a new Redis Streams client instance with the provided configuration.
+// Returns a Stream interface implementation.
+func NewStreamClient(config *StreamConfig) (result Stream, err error) {
+	consumer := config.Consumer
+	if consumer == "" {
+		consumer = "consumer"
+	}
+	block := config.Block
+	if block <= 0 {
+		block = defaultBlock
+	}
+	claimIdle := config.ClaimIdle
+	if claimIdle <= 0 {
+		claimIdle = defaultClaimIdle
+	}
+	return &Client{
+		Redis: redis.NewClient(&redis.Options{
+			Addr: fmt.Sprintf("%s:%s", config.Host, config.Port),
+		}),
+		Consumer:  consumer,
+		block:     block,
+		claimIdle: claimIdle,
+	}, nil
+}